@@ -34,6 +34,7 @@ type book struct {
 	httpRunners      map[string]*httpRunner
 	dbRunners        map[string]*dbRunner
 	grpcRunners      map[string]*grpcRunner
+	wsRunners        map[string]*wsRunner
 	cdpRunners       map[string]*cdpRunner
 	sshRunners       map[string]*sshRunner
 	profile          bool
@@ -50,6 +51,7 @@ type book struct {
 	grpcNoTLS        bool
 	grpcProtos       []string
 	grpcImportPaths  []string
+	grpcProtosets    []string
 	runID            string
 	runMatch         *regexp.Regexp
 	runSample        int
@@ -64,10 +66,19 @@ type book struct {
 	beforeFuncs      []func(*RunResult) error
 	afterFuncs       []func(*RunResult) error
 	capturers        capturers
+	cookieJar        *cookieJar
+	cookieJarFile    string
+	openAPICoverage  *openAPICoverage
 	stdout           io.Writer
 	stderr           io.Writer
 	// skip some errors for `runn list`
 	loadOnly bool
+	// HTTP validators that can be referred from `validators:` of HTTP runners
+	httpValidators map[string]HTTPValidator
+	// HTTP validators appended to HTTP runners
+	httpRunnerValidators map[string][]HTTPValidator
+	// HTTP runners inherited from the parent operator ( their validators are already resolved )
+	inheritedHTTPRunners map[string]*httpRunner
 }
 
 func LoadBook(path string) (*book, error) {
@@ -174,7 +185,7 @@ func (bk *book) parseRunner(k string, v any) error {
 	switch vv := v.(type) {
 	case string:
 		switch {
-		case strings.HasPrefix(vv, "https://") || strings.HasPrefix(vv, "http://"):
+		case isHTTPEndpoint(vv):
 			hc, err := newHTTPRunner(k, vv)
 			if err != nil {
 				return err
@@ -187,6 +198,18 @@ func (bk *book) parseRunner(k string, v any) error {
 				return err
 			}
 			bk.grpcRunners[k] = gc
+		case isGrpcUnixTarget(vv):
+			gc, err := newGrpcRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.grpcRunners[k] = gc
+		case isWSEndpoint(vv):
+			wc, err := newWSRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.wsRunners[k] = wc
 		case strings.HasPrefix(vv, "cdp://") || strings.HasPrefix(vv, "chrome://"):
 			remote := strings.TrimPrefix(strings.TrimPrefix(vv, "cdp://"), "chrome://")
 			cc, err := newCDPRunner(k, remote)
@@ -221,6 +244,14 @@ func (bk *book) parseRunner(k string, v any) error {
 			return err
 		}
 
+		// WebSocket Runner
+		if !detect {
+			detect, err = bk.parseWSRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
 		// gRPC Runner
 		if !detect {
 			detect, err = bk.parseGRPCRunnerWithDetailed(k, tmp)
@@ -250,7 +281,7 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 	if err := yaml.Unmarshal(b, c); err != nil {
 		return false, nil
 	}
-	if c.Endpoint == "" {
+	if c.Endpoint == "" || isWSEndpoint(c.Endpoint) {
 		return false, nil
 	}
 	root, err := bk.generateOperatorRoot()
@@ -270,6 +301,9 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 	if c.OpenApi3DocLocation != "" && !strings.HasPrefix(c.OpenApi3DocLocation, "https://") && !strings.HasPrefix(c.OpenApi3DocLocation, "http://") && !strings.HasPrefix(c.OpenApi3DocLocation, "/") {
 		c.OpenApi3DocLocation = fp(c.OpenApi3DocLocation, root)
 	}
+	if c.Swagger2DocLocation != "" && !strings.HasPrefix(c.Swagger2DocLocation, "https://") && !strings.HasPrefix(c.Swagger2DocLocation, "http://") && !strings.HasPrefix(c.Swagger2DocLocation, "/") {
+		c.Swagger2DocLocation = fp(c.Swagger2DocLocation, root)
+	}
 	if c.CACert != "" {
 		b, err := readFile(fp(c.CACert, root))
 		if err != nil {
@@ -299,11 +333,44 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 		}
 	}
 	r.useCookie = c.UseCookie
+	if err := r.setConnectionOptions(c); err != nil {
+		return false, err
+	}
+	a, err := newHTTPAuth(c.Auth)
+	if err != nil {
+		return false, err
+	}
+	r.auth = a
 	hv, err := newHttpValidator(c)
 	if err != nil {
 		return false, err
 	}
 	r.validator = hv
+	r.validatorNames = c.Validators
+	r.failOnGraphQLErrors = c.FailOnGraphQLErrors
+	return true, nil
+}
+
+func (bk *book) parseWSRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &wsRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if !isWSEndpoint(c.Endpoint) {
+		return false, nil
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return false, err
+	}
+	r, err := newWSRunner(name, c.Endpoint)
+	if err != nil {
+		return false, err
+	}
+	if err := r.setConfig(c, root); err != nil {
+		return false, err
+	}
+	bk.wsRunners[name] = r
 	return true, nil
 }
 
@@ -354,6 +421,46 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 	r.skipVerify = c.SkipVerify
 	r.importPaths = c.ImportPaths
 	r.protos = c.Protos
+	r.protosets = c.Protosets
+	r.bufImage = c.BufImage
+	p, err := newRunnerProxy(c.Proxy, c.NoProxy)
+	if err != nil {
+		return false, err
+	}
+	r.proxy = p
+	hr, err := newHostResolver(c.Resolve)
+	if err != nil {
+		return false, err
+	}
+	r.resolver = hr
+	if err := validateGRPCProtocol(c.Protocol, c.Codec); err != nil {
+		return false, err
+	}
+	if err := validateGRPCOverHTTPOptions(c); err != nil {
+		return false, err
+	}
+	r.protocol = c.Protocol
+	r.codec = c.Codec
+	if c.Credentials != nil && c.Credentials.JWT != nil && c.Credentials.JWT.Key != "" {
+		b, err := readFile(fp(c.Credentials.JWT.Key, root))
+		if err != nil {
+			return false, err
+		}
+		c.Credentials.JWT.key = b
+	}
+	dopts, err := newGrpcDialOptions(c)
+	if err != nil {
+		return false, err
+	}
+	r.dialOpts = dopts
+	if c.Credentials != nil {
+		creds, err := newGrpcPerRPCCredentials(c.Credentials)
+		if err != nil {
+			return false, err
+		}
+		r.perRPCCreds = creds
+	}
+	r.serverName = c.ServerName
 	bk.grpcRunners[name] = r
 	return true, nil
 }
@@ -455,6 +562,26 @@ func (bk *book) applyOptions(opts ...Option) error {
 	return nil
 }
 
+// resolveHTTPValidators chains the OpenAPI validator, the validators referred by `validators:` and the validators appended by HTTPRunnerValidator of each HTTP runner.
+func (bk *book) resolveHTTPValidators() {
+	for k, r := range bk.httpRunners {
+		if ir, ok := bk.inheritedHTTPRunners[k]; ok && ir == r {
+			continue
+		}
+		vs := []HTTPValidator{r.validator}
+		for _, n := range r.validatorNames {
+			hv, ok := bk.httpValidators[n]
+			if !ok {
+				bk.runnerErrs[k] = fmt.Errorf("http validator not found: %s", n)
+				continue
+			}
+			vs = append(vs, hv)
+		}
+		vs = append(vs, bk.httpRunnerValidators[k]...)
+		r.validator = chainHTTPValidators(vs...)
+	}
+}
+
 // generateOperatorRoot generates the root path of the operator.
 func (bk *book) generateOperatorRoot() (string, error) {
 	if bk.path != "" {
@@ -485,6 +612,9 @@ func (bk *book) merge(loaded *book) error {
 	for k, r := range loaded.grpcRunners {
 		bk.grpcRunners[k] = r
 	}
+	for k, r := range loaded.wsRunners {
+		bk.wsRunners[k] = r
+	}
 	for k, r := range loaded.cdpRunners {
 		bk.cdpRunners[k] = r
 	}
@@ -510,6 +640,7 @@ func (bk *book) merge(loaded *book) error {
 	bk.grpcNoTLS = loaded.grpcNoTLS
 	bk.grpcProtos = loaded.grpcProtos
 	bk.grpcImportPaths = loaded.grpcImportPaths
+	bk.grpcProtosets = loaded.grpcProtosets
 	if loaded.intervalStr != "" {
 		bk.interval = loaded.interval
 	}
@@ -555,6 +686,7 @@ func newBook() *book {
 		httpRunners: map[string]*httpRunner{},
 		dbRunners:   map[string]*dbRunner{},
 		grpcRunners: map[string]*grpcRunner{},
+		wsRunners:   map[string]*wsRunner{},
 		cdpRunners:  map[string]*cdpRunner{},
 		sshRunners:  map[string]*sshRunner{},
 		interval:    0 * time.Second,
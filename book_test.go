@@ -151,3 +151,92 @@ func TestParseRunnerForHttpRunner(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRunnerForGrpcRunnerDialOptions(t *testing.T) {
+	tests := []struct {
+		v            map[string]any
+		wantDialOpts int
+		wantCreds    bool
+		wantErr      bool
+	}{
+		{
+			map[string]any{"addr": "grpc.example.com:443"},
+			0,
+			false,
+			false,
+		},
+		{
+			map[string]any{
+				"addr":           "grpc.example.com:443",
+				"compression":    "gzip",
+				"maxRecvMsgSize": 67108864,
+				"keepalive":      map[string]any{"time": "30sec"},
+				"retryPolicy":    map[string]any{"maxAttempts": 3},
+				"authority":      "api.example.com",
+				"credentials":    map[string]any{"bearer": map[string]any{"token": "xxxxx"}},
+			},
+			4,
+			true,
+			false,
+		},
+		{
+			map[string]any{
+				"addr":        "https://grpc.example.com",
+				"protocol":    "connect",
+				"protos":      []any{"grpctest.proto"},
+				"credentials": map[string]any{"bearer": map[string]any{"token": "xxxxx"}},
+			},
+			0,
+			true,
+			false,
+		},
+		{
+			map[string]any{
+				"addr":        "https://grpc.example.com",
+				"protocol":    "connect",
+				"compression": "gzip",
+			},
+			0,
+			false,
+			true,
+		},
+		{
+			map[string]any{
+				"addr":        "grpc.example.com:443",
+				"credentials": map[string]any{"bearer": map[string]any{"token": "xxxxx"}, "oauth2": map[string]any{"tokenURL": "https://auth.example.com/token", "clientID": "runn"}},
+			},
+			0,
+			false,
+			true,
+		},
+		{
+			map[string]any{
+				"addr":        "grpc.example.com:443",
+				"retryPolicy": map[string]any{"maxAttempts": 1},
+			},
+			0,
+			false,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		bk := newBook()
+		if err := bk.parseRunner("greq", tt.v); err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+			continue
+		}
+		got := bk.grpcRunners["greq"]
+		if len(got.dialOpts) != tt.wantDialOpts {
+			t.Errorf("got %v\nwant %v", len(got.dialOpts), tt.wantDialOpts)
+		}
+		if (got.perRPCCreds != nil) != tt.wantCreds {
+			t.Errorf("got %v\nwant %v", got.perRPCCreds != nil, tt.wantCreds)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 )
 
 var _ runn.Capturer = (*cRunbook)(nil)
+var _ runn.WSCapturer = (*cRunbook)(nil)
 
 type cRunbook struct {
 	dir           string
@@ -40,6 +41,9 @@ type runbook struct {
 	currentGRPCResponceIndex int
 	currentGRPCTestCond      []string
 	currentExecTestCond      []string
+	currentWSTestCond        []string
+	currentWSResponseIndex   int
+	currentWSClosing         bool
 }
 
 type RunbookOption func(*cRunbook) error
@@ -317,6 +321,128 @@ func (c *cRunbook) CaptureGRPCEnd(name string, typ runn.GRPCType, service, metho
 	r.currentGRPCResponceIndex = 0
 }
 
+func (c *cRunbook) CaptureWSStart(name, endpoint string) {
+	const dummyEndpoint = "[THIS IS WebSocket RUNNER]"
+	if v, ok := c.runners[name]; ok {
+		c.setRunner(name, v)
+	} else {
+		c.setRunner(name, dummyEndpoint)
+	}
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	step := yaml.MapSlice{
+		{Key: name, Value: yaml.MapSlice{}},
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+func (c *cRunbook) CaptureWSHandshake(h map[string][]string, protocol string) {
+	hh := map[string]any{}
+	for k, v := range h {
+		if len(v) == 0 {
+			continue
+		}
+		if strings.EqualFold(k, "User-Agent") && strings.HasPrefix(v[0], "runn/") {
+			continue
+		}
+		if len(v) == 1 {
+			hh[k] = v[0]
+			continue
+		}
+		var vv []any
+		for _, s := range v {
+			vv = append(vv, s)
+		}
+		hh[k] = vv
+	}
+	if len(hh) == 0 {
+		return
+	}
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	step := r.latestStep()
+	hb := wsHeadersAndMessages(step)
+	hb = append(yaml.MapSlice{{Key: "headers", Value: hh}}, hb...)
+	r.replaceLatestStep(replaceWSHeadersAndMessages(step, hb))
+}
+
+func (c *cRunbook) CaptureWSRequestMessage(m map[string]any) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	step := r.latestStep()
+	hb := wsHeadersAndMessages(step)
+	hb = c.appendOp(hb, map[string]any{fmt.Sprintf("%v", m["type"]): m["rawData"]})
+	r.replaceLatestStep(replaceWSHeadersAndMessages(step, hb))
+}
+
+func (c *cRunbook) CaptureWSResponseMessage(m map[string]any) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	if r.currentWSClosing {
+		// Data messages received while closing are not recorded
+		return
+	}
+	step := r.latestStep()
+	hb := wsHeadersAndMessages(step)
+	hb = c.appendOp(hb, string(runn.WSOpReceive))
+	r.replaceLatestStep(replaceWSHeadersAndMessages(step, hb))
+	r.currentWSTestCond = append(r.currentWSTestCond, fmt.Sprintf("current.res.messages[%d].rawData == %#v", r.currentWSResponseIndex, m["rawData"]))
+	r.currentWSResponseIndex += 1
+}
+
+func (c *cRunbook) CaptureWSClientClose() {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	step := r.latestStep()
+	hb := wsHeadersAndMessages(step)
+	hb = c.appendOp(hb, string(runn.WSOpClose))
+	r.replaceLatestStep(replaceWSHeadersAndMessages(step, hb))
+	r.currentWSClosing = true
+}
+
+func (c *cRunbook) CaptureWSClose(code int, reason string) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	if !r.currentWSClosing {
+		// The server closed the connection while receiving
+		step := r.latestStep()
+		hb := wsHeadersAndMessages(step)
+		hb = c.appendOp(hb, string(runn.WSOpReceive))
+		r.replaceLatestStep(replaceWSHeadersAndMessages(step, hb))
+	}
+	r.currentWSTestCond = append(r.currentWSTestCond, fmt.Sprintf("current.res.close.code == %d", code))
+}
+
+func (c *cRunbook) CaptureWSEnd(name, endpoint string) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	defer func() {
+		r.currentWSTestCond = nil
+		r.currentWSResponseIndex = 0
+		r.currentWSClosing = false
+	}()
+	if len(r.currentWSTestCond) == 0 {
+		return
+	}
+	step := r.latestStep()
+	step = append(step, yaml.MapItem{Key: "test", Value: fmt.Sprintf("%s\n", strings.Join(r.currentWSTestCond, "\n&& "))})
+	r.replaceLatestStep(step)
+}
+
 func (c *cRunbook) CaptureCDPStart(name string) {
 	// FIXME: not implemented
 }
@@ -568,6 +694,15 @@ func replaceHeadersAndMessages(step, hb yaml.MapSlice) yaml.MapSlice {
 	return step
 }
 
+func wsHeadersAndMessages(step yaml.MapSlice) yaml.MapSlice {
+	return step[0].Value.(yaml.MapSlice)
+}
+
+func replaceWSHeadersAndMessages(step, hb yaml.MapSlice) yaml.MapSlice {
+	step[0].Value = hb
+	return step
+}
+
 // copy from net/http/httputil.
 func drainBody(b io.ReadCloser) (r1, r2 io.ReadCloser, err error) {
 	if b == nil || b == http.NoBody {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"testing"
 
@@ -22,6 +23,7 @@ func TestRunbook(t *testing.T) {
 		{filepath.Join(testutil.Testdata(), "book", "grpc.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "db.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "exec.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "ws.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "include_main.yml")},
 	}
 	ctx := context.Background()
@@ -31,11 +33,13 @@ func TestRunbook(t *testing.T) {
 			hs := testutil.HTTPServer(t)
 			gs := testutil.GRPCServer(t, false, false)
 			db, _ := testutil.SQLite(t)
+			ws := testutil.WSEchoServer(t)
 			opts := []runn.Option{
 				runn.Book(tt.book),
 				runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
 				runn.GrpcRunner("greq", gs.Conn()),
 				runn.DBRunner("db", db),
+				runn.WSRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
 				runn.Capture(Runbook(dir)),
 			}
 			o, err := runn.New(opts...)
@@ -69,6 +73,7 @@ func TestRunnable(t *testing.T) {
 		{filepath.Join(testutil.Testdata(), "book", "grpc.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "db.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "exec.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "ws.yml")},
 	}
 	ctx := context.Background()
 	for _, tt := range tests {
@@ -78,11 +83,13 @@ func TestRunnable(t *testing.T) {
 				hs := testutil.HTTPServer(t)
 				gs := testutil.GRPCServer(t, false, false)
 				db, _ := testutil.SQLite(t)
+				ws := testutil.WSEchoServer(t)
 				opts := []runn.Option{
 					runn.Book(tt.book),
 					runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
 					runn.GrpcRunner("greq", gs.Conn()),
 					runn.DBRunner("db", db),
+					runn.WSRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
 					runn.Capture(Runbook(dir)),
 				}
 				o, err := runn.New(opts...)
@@ -98,11 +105,13 @@ func TestRunnable(t *testing.T) {
 				hs := testutil.HTTPServer(t)
 				gs := testutil.GRPCServer(t, false, false)
 				db, _ := testutil.SQLite(t)
+				ws := testutil.WSEchoServer(t)
 				opts := []runn.Option{
 					runn.Book(filepath.Join(dir, capturedFilename(tt.book))),
 					runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
 					runn.GrpcRunner("greq", gs.Conn()),
 					runn.DBRunner("db", db),
+					runn.WSRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
 				}
 				o, err := runn.New(opts...)
 				if err != nil {
@@ -46,6 +46,24 @@ type Capturer interface {
 	Errs() error
 }
 
+// HTTPTimingsCapturer is the Capturer that captures the timing breakdown of HTTP requests.
+// It is optional for Capturer.
+type HTTPTimingsCapturer interface {
+	CaptureHTTPTimings(name string, t *HTTPTimings)
+}
+
+// WSCapturer is the Capturer that captures WebSocket sessions.
+// It is optional for Capturer.
+type WSCapturer interface {
+	CaptureWSStart(name, endpoint string)
+	CaptureWSHandshake(h map[string][]string, protocol string)
+	CaptureWSRequestMessage(m map[string]any)
+	CaptureWSResponseMessage(m map[string]any)
+	CaptureWSClientClose()
+	CaptureWSClose(code int, reason string)
+	CaptureWSEnd(name, endpoint string)
+}
+
 type capturers []Capturer
 
 func (cs capturers) captureStart(trs Trails, bookPath, desc string) { //nostyle:recvtype
@@ -78,6 +96,14 @@ func (cs capturers) captureHTTPResponse(name string, res *http.Response) { //nos
 	}
 }
 
+func (cs capturers) captureHTTPTimings(name string, t *HTTPTimings) { //nostyle:recvtype
+	for _, c := range cs {
+		if tc, ok := c.(HTTPTimingsCapturer); ok {
+			tc.CaptureHTTPTimings(name, t)
+		}
+	}
+}
+
 func (cs capturers) captureGRPCStart(name string, typ GRPCType, service, method string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureGRPCStart(name, typ, service, method)
@@ -131,6 +157,62 @@ func (cs capturers) captureGRPCEnd(name string, typ GRPCType, service, method st
 	}
 }
 
+func (cs capturers) captureWSStart(name, endpoint string) { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSStart(name, endpoint)
+		}
+	}
+}
+
+func (cs capturers) captureWSHandshake(h map[string][]string, protocol string) { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSHandshake(h, protocol)
+		}
+	}
+}
+
+func (cs capturers) captureWSRequestMessage(m map[string]any) { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSRequestMessage(m)
+		}
+	}
+}
+
+func (cs capturers) captureWSResponseMessage(m map[string]any) { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSResponseMessage(m)
+		}
+	}
+}
+
+func (cs capturers) captureWSClientClose() { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSClientClose()
+		}
+	}
+}
+
+func (cs capturers) captureWSClose(code int, reason string) { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSClose(code, reason)
+		}
+	}
+}
+
+func (cs capturers) captureWSEnd(name, endpoint string) { //nostyle:recvtype
+	for _, c := range cs {
+		if wc, ok := c.(WSCapturer); ok {
+			wc.CaptureWSEnd(name, endpoint)
+		}
+	}
+}
+
 func (cs capturers) captureCDPStart(name string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureCDPStart(name)
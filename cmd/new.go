@@ -29,6 +29,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/k1LoW/runn"
 	"github.com/k1LoW/runn/capture"
@@ -44,6 +46,9 @@ var newCmd = &cobra.Command{
 	Long:    `create new runbook or append step to runbook.`,
 	Aliases: []string{"append"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if flgs.FromOpenAPI != "" {
+			return newFromOpenAPI3(flgs.FromOpenAPI, flgs.Out, flgs.ByTag)
+		}
 		var (
 			o   *os.File
 			err error
@@ -122,9 +127,74 @@ func init() {
 	newCmd.Flags().StringVarP(&flgs.Desc, "desc", "", "", flgs.Usage("Desc"))
 	newCmd.Flags().StringVarP(&flgs.Out, "out", "", "", flgs.Usage("Out"))
 	newCmd.Flags().BoolVarP(&flgs.AndRun, "and-run", "", false, flgs.Usage("AndRun"))
+	newCmd.Flags().StringVarP(&flgs.FromOpenAPI, "from-openapi", "", "", flgs.Usage("FromOpenAPI"))
+	newCmd.Flags().BoolVarP(&flgs.ByTag, "by-tag", "", false, flgs.Usage("ByTag"))
 	newCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
+	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtosets, "grpc-protoset", "", []string{}, flgs.Usage("GRPCProtosets"))
+}
+
+// newFromOpenAPI3 creates runbooks from the OpenAPI 3 document.
+// If out is empty, runbooks are written to STDOUT, otherwise to files in the out directory.
+func newFromOpenAPI3(location, out string, byTag bool) error {
+	if flgs.AndRun {
+		return errors.New("--and-run cannot be used with --from-openapi")
+	}
+	ref := location
+	if out != "" && !strings.HasPrefix(location, "https://") && !strings.HasPrefix(location, "http://") {
+		// the runbooks refer to the document by a path relative to them
+		abs, err := filepath.Abs(location)
+		if err != nil {
+			return err
+		}
+		absOut, err := filepath.Abs(out)
+		if err != nil {
+			return err
+		}
+		ref, err = filepath.Rel(absOut, abs)
+		if err != nil {
+			return err
+		}
+	}
+	rbs, err := runn.NewRunbooksFromOpenAPI3(location, ref, byTag)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for n := range rbs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	if out == "" {
+		enc := yaml.NewEncoder(os.Stdout)
+		for _, n := range names {
+			if err := enc.Encode(rbs[n]); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	}
+	if err := os.MkdirAll(out, os.ModePerm); err != nil {
+		return err
+	}
+	for _, n := range names {
+		p := filepath.Join(out, fmt.Sprintf("%s.yml", n))
+		f, err := os.Create(filepath.Clean(p))
+		if err != nil {
+			return err
+		}
+		if err := yaml.NewEncoder(f).Encode(rbs[n]); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", p)
+	}
+	return nil
 }
 
 func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) error {
@@ -155,6 +225,7 @@ func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) err
 		runn.GRPCNoTLS(flgs.GRPCNoTLS),
 		runn.GRPCProtos(flgs.GRPCProtos),
 		runn.GRPCImportPaths(flgs.GRPCImportPaths),
+		runn.GRPCProtosets(flgs.GRPCProtosets),
 	}
 	oo, err := runn.New(opts...)
 	if err != nil {
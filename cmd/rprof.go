@@ -108,6 +108,8 @@ var rprofCmd = &cobra.Command{
 				id = fmt.Sprintf("%sbeforeFunc[%d]", strings.Repeat("  ", rr.depth), rr.trail.FuncIndex)
 			case runn.TrailTypeAfterFunc:
 				id = fmt.Sprintf("%safterFunc[%d]", strings.Repeat("  ", rr.depth), rr.trail.FuncIndex)
+			case runn.TrailTypeHTTPTiming:
+				id = fmt.Sprintf("%s%s", strings.Repeat("  ", rr.depth), rr.trail.Desc)
 			default:
 				return fmt.Errorf("invalid runID type: %s", rr.trail.Type)
 			}
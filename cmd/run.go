@@ -95,6 +95,26 @@ var runCmd = &cobra.Command{
 			}
 		}
 
+		if flgs.OpenAPICoverage != "" || flgs.OpenAPICoverageThreshold > 0 {
+			covs := o.OpenAPICoverages()
+			switch flgs.OpenAPICoverage {
+			case "":
+			case "table":
+				if err := covs.Out(os.Stdout); err != nil {
+					return err
+				}
+			case "json":
+				if err := covs.OutJSON(os.Stdout); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("invalid openapi coverage format: %s", flgs.OpenAPICoverage)
+			}
+			if err := covs.CheckThreshold(flgs.OpenAPICoverageThreshold); err != nil {
+				return err
+			}
+		}
+
 		if r.HasFailure() {
 			os.Exit(1)
 		}
@@ -111,7 +131,12 @@ func init() {
 	runCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
+	runCmd.Flags().StringSliceVarP(&flgs.GRPCProtosets, "grpc-protoset", "", []string{}, flgs.Usage("GRPCProtosets"))
 	runCmd.Flags().StringVarP(&flgs.CaptureDir, "capture", "", "", flgs.Usage("CaptureDir"))
+	runCmd.Flags().BoolVarP(&flgs.ShareCookies, "share-cookies", "", false, flgs.Usage("ShareCookies"))
+	runCmd.Flags().StringVarP(&flgs.CookieJar, "cookie-jar", "", "", flgs.Usage("CookieJar"))
+	runCmd.Flags().StringVarP(&flgs.OpenAPICoverage, "openapi-coverage", "", "", flgs.Usage("OpenAPICoverage"))
+	runCmd.Flags().Float64VarP(&flgs.OpenAPICoverageThreshold, "openapi-coverage-threshold", "", 0, flgs.Usage("OpenAPICoverageThreshold"))
 	runCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
 	runCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
 	runCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
@@ -0,0 +1,294 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/net/publicsuffix"
+)
+
+// cookieJar is the cookie jar that stores and sends cookies using net/http/cookiejar with the public suffix list.
+// Since net/http/cookiejar does not expose the stored cookies, the accepted cookies are also kept in the index
+// to list them for `cookies` in expressions and to persist them to the file.
+type cookieJar struct {
+	jar *cookiejar.Jar
+	// entries is the index of the cookies accepted by jar
+	entries map[string]*cookieEntry
+	nextSeq uint64
+	// file is the path to load and save cookies
+	file   string
+	loaded bool
+	// recorded is whether cookies have been set or loaded ( `cookies` is stored after that even if all cookies are removed )
+	recorded bool
+	mu       sync.Mutex
+}
+
+type cookieEntry struct {
+	// URL is the request URL that set the cookie. It is used to set the cookie to jar again when loading from the file
+	URL string `json:"url"`
+	// Cookie is the cookie as received
+	Cookie  *http.Cookie `json:"cookie"`
+	Expires time.Time    `json:"expires"`
+	key     string
+	domain  string
+	path    string
+	seq     uint64
+}
+
+func newCookieJar() *cookieJar {
+	// cookiejar.New never returns an error
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return &cookieJar{
+		jar:     jar,
+		entries: map[string]*cookieEntry{},
+	}
+}
+
+func (e *cookieEntry) id() string {
+	return fmt.Sprintf("%s;%s;%s", e.domain, e.path, e.Cookie.Name)
+}
+
+func (e *cookieEntry) persistent() bool {
+	return !e.Expires.IsZero()
+}
+
+func (e *cookieEntry) expired(now time.Time) bool {
+	return e.persistent() && !e.Expires.After(now)
+}
+
+// Cookies implements http.CookieJar.
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar.
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.recorded = true
+	for _, c := range cookies {
+		j.setCookie(u, c, now)
+	}
+}
+
+// setCookie sets the cookie to jar and updates the index according to whether jar has accepted it.
+func (j *cookieJar) setCookie(u *url.URL, c *http.Cookie, now time.Time) bool {
+	j.jar.SetCookies(u, []*http.Cookie{c})
+	e, err := newCookieEntry(u, c, now)
+	if err != nil {
+		return false
+	}
+	id := e.id()
+	if c.MaxAge < 0 || e.expired(now) {
+		delete(j.entries, id)
+		return false
+	}
+	if !j.accepted(u, e) {
+		return false
+	}
+	if old, ok := j.entries[id]; ok {
+		e.seq = old.seq
+	} else {
+		e.seq = j.nextSeq
+		j.nextSeq++
+	}
+	j.entries[id] = e
+	return true
+}
+
+// accepted returns whether jar sends the cookie of the entry back to the host and path that set it.
+func (j *cookieJar) accepted(u *url.URL, e *cookieEntry) bool {
+	for _, c := range j.jar.Cookies(&url.URL{Scheme: "https", Host: u.Host, Path: e.path}) {
+		if c.Name == e.Cookie.Name && c.Value == e.Cookie.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// toMap returns stored cookies as map[domain]map[name]*http.Cookie for `cookies` in expressions.
+// The key of cookies without the Domain attribute is the host ( with port ) of the request URL.
+func (j *cookieJar) toMap() map[string]map[string]*http.Cookie {
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	m := map[string]map[string]*http.Cookie{}
+	var entries []*cookieEntry
+	for id, e := range j.entries {
+		if e.expired(now) {
+			delete(j.entries, id)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	// Cookies with longer paths take precedence over cookies with the same name
+	sort.Slice(entries, func(i, k int) bool {
+		if len(entries[i].path) != len(entries[k].path) {
+			return len(entries[i].path) < len(entries[k].path)
+		}
+		return entries[i].seq < entries[k].seq
+	})
+	for _, e := range entries {
+		if _, ok := m[e.key]; !ok {
+			m[e.key] = map[string]*http.Cookie{}
+		}
+		m[e.key][e.Cookie.Name] = e.Cookie
+	}
+	return m
+}
+
+// hasRecorded returns whether cookies have been set or loaded.
+func (j *cookieJar) hasRecorded() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.recorded
+}
+
+// setFile sets the file to load and save cookies, and loads cookies from the file if it exists.
+func (j *cookieJar) setFile(p string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.loaded && j.file == p {
+		return nil
+	}
+	j.file = p
+	j.loaded = true
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to load cookies: %w", err)
+	}
+	var entries []*cookieEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return fmt.Errorf("failed to load cookies: %s: %w", p, err)
+	}
+	now := time.Now()
+	for _, e := range entries {
+		if e.Cookie == nil || e.expired(now) {
+			continue
+		}
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			continue
+		}
+		// Max-Age is relative to the time the cookie was set, so set the cookie with the expiry time instead
+		c := *e.Cookie
+		c.MaxAge = 0
+		c.Expires = e.Expires
+		if j.setCookie(u, &c, now) {
+			j.recorded = true
+		}
+	}
+	return nil
+}
+
+// save saves persistent cookies to the file. Session cookies are not saved.
+func (j *cookieJar) save() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == "" {
+		return nil
+	}
+	now := time.Now()
+	entries := []*cookieEntry{}
+	for _, e := range j.entries {
+		if !e.persistent() || e.expired(now) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, k int) bool {
+		return entries[i].seq < entries[k].seq
+	})
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.file), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+	if err := os.WriteFile(j.file, b, 0600); err != nil {
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+	return nil
+}
+
+// newCookieEntry creates the index entry of the cookie set by the response of the request URL.
+// Whether the cookie is accepted is decided by jar, so the entry only identifies the cookie in the same way as jar.
+func newCookieEntry(u *url.URL, c *http.Cookie, now time.Time) (*cookieEntry, error) {
+	host := u.Hostname()
+	if host == "" {
+		return nil, errors.New("empty host")
+	}
+	e := &cookieEntry{
+		URL:    (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawPath: u.RawPath}).String(),
+		Cookie: c,
+		key:    u.Host,
+		domain: strings.TrimSuffix(strings.ToLower(host), "."),
+		path:   c.Path,
+	}
+	if c.Domain != "" && net.ParseIP(host) == nil {
+		e.domain = strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+		e.key = e.domain
+	}
+	if e.path == "" || e.path[0] != '/' {
+		e.path = defaultCookiePath(u.Path)
+	}
+	switch {
+	case c.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		e.Expires = c.Expires
+	}
+	return e, nil
+}
+
+// defaultCookiePath returns the default-path of RFC 6265 section 5.1.4.
+func defaultCookiePath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// cookieRecorder is the http.CookieJar that only stores cookies of responses (including redirects) to the cookie jar.
+// Cookies are set to requests by the runner to capture and validate requests with cookies.
+type cookieRecorder struct {
+	jar *cookieJar
+}
+
+func (r *cookieRecorder) Cookies(u *url.URL) []*http.Cookie {
+	return nil
+}
+
+func (r *cookieRecorder) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	r.jar.SetCookies(u, cookies)
+}
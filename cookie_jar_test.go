@@ -0,0 +1,265 @@
+package runn
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCookieJarSetCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		setURL  string
+		cookies []*http.Cookie
+		url     string
+		want    []string
+	}{
+		{
+			"host-only",
+			"http://example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1"}},
+			"http://sub.example.com/",
+			[]string{},
+		},
+		{
+			"domain",
+			"http://sub.example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1", Domain: ".example.com"}},
+			"http://www.example.com/",
+			[]string{"a=1"},
+		},
+		{
+			"domain of other site",
+			"http://example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1", Domain: "example.org"}},
+			"http://example.org/",
+			[]string{},
+		},
+		{
+			"public suffix",
+			"http://www.example.co.jp/",
+			[]*http.Cookie{{Name: "a", Value: "1", Domain: "co.jp"}},
+			"http://other.co.jp/",
+			[]string{},
+		},
+		{
+			"default path",
+			"http://example.com/users/login",
+			[]*http.Cookie{{Name: "a", Value: "1"}},
+			"http://example.com/",
+			[]string{},
+		},
+		{
+			"default path match",
+			"http://example.com/users/login",
+			[]*http.Cookie{{Name: "a", Value: "1"}},
+			"http://example.com/users/k1LoW",
+			[]string{"a=1"},
+		},
+		{
+			"remove by max-age",
+			"http://example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1"}, {Name: "a", Value: "", MaxAge: -1}},
+			"http://example.com/",
+			[]string{},
+		},
+		{
+			"remove by expires",
+			"http://example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1"}, {Name: "a", Value: "", Expires: time.Unix(0, 0)}},
+			"http://example.com/",
+			[]string{},
+		},
+		{
+			"override",
+			"http://example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1"}, {Name: "a", Value: "2"}},
+			"http://example.com/",
+			[]string{"a=2"},
+		},
+		{
+			"secure over http",
+			"https://example.com/",
+			[]*http.Cookie{{Name: "a", Value: "1", Secure: true}},
+			"http://example.com/",
+			[]string{},
+		},
+		{
+			"secure over localhost",
+			"http://localhost:8080/",
+			[]*http.Cookie{{Name: "a", Value: "1", Secure: true}},
+			"http://localhost:8080/",
+			[]string{"a=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jar := newCookieJar()
+			jar.SetCookies(pathToURL(t, tt.setURL), tt.cookies)
+			got := []string{}
+			for _, c := range jar.Cookies(pathToURL(t, tt.url)) {
+				got = append(got, c.String())
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCookieJarToMap(t *testing.T) {
+	type set struct {
+		url     string
+		cookies []*http.Cookie
+	}
+	tests := []struct {
+		name string
+		sets []set
+		want map[string]map[string]string
+	}{
+		{
+			"no cookies",
+			[]set{{"http://example.com/", []*http.Cookie{}}},
+			map[string]map[string]string{},
+		},
+		{
+			"host-only cookie is keyed by the host with port",
+			[]set{{"http://localhost:8080/", []*http.Cookie{{Name: "a", Value: "1"}}}},
+			map[string]map[string]string{"localhost:8080": {"a": "1"}},
+		},
+		{
+			"domain cookies",
+			[]set{{"http://www.example.com/", []*http.Cookie{{Name: "key1", Value: "value1", Domain: "example.com"}, {Name: "key2", Value: "value2", Domain: "example.com"}}}},
+			map[string]map[string]string{"example.com": {"key1": "value1", "key2": "value2"}},
+		},
+		{
+			"cookies of multiple domains",
+			[]set{{"http://sub.example.com/", []*http.Cookie{{Name: "key1", Value: "value1", Domain: "example.com"}, {Name: "key3", Value: "value3", Domain: "sub.example.com"}}}},
+			map[string]map[string]string{"example.com": {"key1": "value1"}, "sub.example.com": {"key3": "value3"}},
+		},
+		{
+			"override",
+			[]set{
+				{"http://example.com/", []*http.Cookie{{Name: "key1", Value: "value1", Domain: "example.com"}}},
+				{"http://example.com/", []*http.Cookie{{Name: "key1", Value: "value4", Domain: "example.com"}}},
+			},
+			map[string]map[string]string{"example.com": {"key1": "value4"}},
+		},
+		{
+			"expire",
+			[]set{
+				{"http://example.com/", []*http.Cookie{{Name: "key1", Value: "value1", Domain: "example.com"}}},
+				{"http://example.com/", []*http.Cookie{{Name: "key1", Value: "value4", Domain: "example.com", Expires: time.Now()}}},
+			},
+			map[string]map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jar := newCookieJar()
+			for _, s := range tt.sets {
+				jar.SetCookies(pathToURL(t, s.url), s.cookies)
+			}
+			got := map[string]map[string]string{}
+			for k, cs := range jar.toMap() {
+				got[k] = map[string]string{}
+				for n, c := range cs {
+					got[k][n] = c.Value
+				}
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCookieJarToMapKeepsCookieAsReceived(t *testing.T) {
+	jar := newCookieJar()
+	jar.SetCookies(pathToURL(t, "http://localhost:8080/"), []*http.Cookie{{Name: "a", Value: "1"}})
+	c, ok := jar.toMap()["localhost:8080"]["a"]
+	if !ok {
+		t.Fatal("cookie not found")
+	}
+	if c.Domain != "" {
+		t.Errorf("got %v\nwant %v", c.Domain, "")
+	}
+}
+
+func TestCookieJarFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cookies.json")
+	u := &url.URL{Scheme: "https", Host: "example.com", Path: "/"}
+	jar := newCookieJar()
+	if err := jar.setFile(p); err != nil {
+		t.Fatal(err)
+	}
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "1"},
+		{Name: "persistent", Value: "2", MaxAge: 3600},
+	})
+	if err := jar.save(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fi.Mode().Perm(); got != 0600 {
+		t.Errorf("got %v\nwant %v", got, os.FileMode(0600))
+	}
+
+	loaded := newCookieJar()
+	if err := loaded.setFile(p); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, c := range loaded.Cookies(u) {
+		got = append(got, c.String())
+	}
+	if diff := cmp.Diff(got, []string{"persistent=2"}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCookieJarFileSharedAcrossRunbooks(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cookies.json")
+	opts := []Option{CookieJarFile(p)}
+	o1, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o2, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o1.store.cookieJar != o2.store.cookieJar {
+		t.Fatal("the cookie jar should be shared when the cookie jar file is set")
+	}
+	o1.store.cookieJar.SetCookies(pathToURL(t, "https://a.example.com/"), []*http.Cookie{{Name: "a", Value: "1", MaxAge: 3600}})
+	o2.store.cookieJar.SetCookies(pathToURL(t, "https://b.example.com/"), []*http.Cookie{{Name: "b", Value: "2", MaxAge: 3600}})
+	if err := o2.store.cookieJar.save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o1.store.cookieJar.save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newCookieJar()
+	if err := loaded.setFile(p); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for k, cs := range loaded.toMap() {
+		for n, c := range cs {
+			got[k] = n + "=" + c.Value
+		}
+	}
+	want := map[string]string{"a.example.com": "a=1", "b.example.com": "b=2"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -15,6 +15,8 @@ import (
 )
 
 var _ Capturer = (*debugger)(nil)
+var _ HTTPTimingsCapturer = (*debugger)(nil)
+var _ WSCapturer = (*debugger)(nil)
 
 type debugger struct {
 	out           io.Writer
@@ -42,6 +44,10 @@ func (d *debugger) CaptureHTTPResponse(name string, res *http.Response) {
 	_, _ = fmt.Fprintf(d.out, "-----START HTTP RESPONSE-----\n%s\n-----END HTTP RESPONSE-----\n", string(b))
 }
 
+func (d *debugger) CaptureHTTPTimings(name string, t *HTTPTimings) {
+	_, _ = fmt.Fprintf(d.out, "-----START HTTP TIMINGS-----\ndns: %s, connect: %s, tls: %s, ttfb: %s, transfer: %s, total: %s\n-----END HTTP TIMINGS-----\n", t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer, t.Total)
+}
+
 func (d *debugger) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
 	_, _ = fmt.Fprintf(d.out, ">>>>>START gRPC (%s/%s)>>>>>\n", service, method)
 }
@@ -81,6 +87,32 @@ func (d *debugger) CaptureGRPCEnd(name string, typ GRPCType, service, method str
 	_, _ = fmt.Fprintf(d.out, "<<<<<END gRPC (%s/%s)<<<<<\n", service, method)
 }
 
+func (d *debugger) CaptureWSStart(name, endpoint string) {
+	_, _ = fmt.Fprintf(d.out, ">>>>>START WebSocket (%s)>>>>>\n", endpoint)
+}
+
+func (d *debugger) CaptureWSHandshake(h map[string][]string, protocol string) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket HANDSHAKE-----\nheaders:\n%s\nprotocol: %s\n-----END WebSocket HANDSHAKE-----\n", dumpGRPCMetadata(h), protocol)
+}
+
+func (d *debugger) CaptureWSRequestMessage(m map[string]any) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket REQUEST MESSAGE-----\n%s: %s\n-----END WebSocket REQUEST MESSAGE-----\n", m[wsMessageTypeKey], m[wsMessageRawDataKey])
+}
+
+func (d *debugger) CaptureWSResponseMessage(m map[string]any) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket RESPONSE MESSAGE-----\n%s: %s\n-----END WebSocket RESPONSE MESSAGE-----\n", m[wsMessageTypeKey], m[wsMessageRawDataKey])
+}
+
+func (d *debugger) CaptureWSClientClose() {}
+
+func (d *debugger) CaptureWSClose(code int, reason string) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket CLOSE-----\ncode: %d, reason: %s\n-----END WebSocket CLOSE-----\n", code, reason)
+}
+
+func (d *debugger) CaptureWSEnd(name, endpoint string) {
+	_, _ = fmt.Fprintf(d.out, "<<<<<END WebSocket (%s)<<<<<\n", endpoint)
+}
+
 func (d *debugger) CaptureCDPStart(name string) {
 	_, _ = fmt.Fprint(d.out, ">>>>>START CDP>>>>>\n")
 }
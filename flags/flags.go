@@ -21,44 +21,51 @@ var intRe = regexp.MustCompile(`^\-?[0-9]+$`)
 var floatRe = regexp.MustCompile(`^\-?[0-9.]+$`)
 
 type Flags struct {
-	Debug           bool     `usage:"debug"`
-	Long            bool     `usage:"long format"`
-	FailFast        bool     `usage:"fail fast"`
-	SkipTest        bool     `usage:"skip \"test:\" section"`
-	SkipIncluded    bool     `usage:"skip running the included runbook by itself"`
-	RunMatch        string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
-	RunID           string   `usage:"run the matching runbook if there is only one runbook with a forward matching ID"`
-	GRPCNoTLS       bool     `usage:"disable TLS use in all gRPC runners"`
-	GRPCProtos      []string `usage:"set the name of proto source for all gRPC runners"`
-	GRPCImportPaths []string `usage:"set the path to the directory where proto sources can be imported for all gRPC runners"`
-	CaptureDir      string   `usage:"destination of runbook run capture results"`
-	Vars            []string `usage:"set var to runbook (\"key:value\")"`
-	Runners         []string `usage:"set runner to runbook (\"key:dsn\")"`
-	Overlays        []string `usage:"overlay values on the runbook"`
-	Underlays       []string `usage:"lay values under the runbook"`
-	Sample          int      `usage:"sample the specified number of runbooks"`
-	Shuffle         string   `usage:"randomize the order of running runbooks (\"on\",\"off\",N)"`
-	Concurrent      string   `usage:"run runbooks concurrently (\"on\",\"off\",N)"`
-	ShardIndex      int      `usage:"index of distributed runbooks"`
-	ShardN          int      `usage:"number of shards for distributing runbooks"`
-	Random          int      `usage:"run the specified number of runbooks at random"`
-	Desc            string   `usage:"description of runbook"`
-	Out             string   `usage:"target path of runbook"`
-	Format          string   `usage:"format of result output"`
-	AndRun          bool     `usage:"run created runbook and capture the response for test"`
-	LoadTConcurrent int      `usage:"number of concurrent load test runs"`
-	LoadTDuration   string   `usage:"load test running duration"`
-	LoadTWarmUp     string   `usage:"warn-up time for load test"`
-	LoadTThreshold  string   `usage:"if this threshold condition is not met, loadt command returns exit status 1 (EXIT_FAILURE)"`
-	LoadTMaxRPS     int      `usage:"max RunN per second for load test. 0 means unlimited."`
-	Profile         bool     `usage:"profile runs of runbooks"`
-	ProfileOut      string   `usage:"profile output path"`
-	ProfileDepth    int      `usage:"depth of profile"`
-	ProfileUnit     string   `usage:"-"`
-	ProfileSort     string   `usage:"-"`
-	CacheDir        string   `usage:"specify cache directory for remote runbooks"`
-	RetainCacheDir  bool     `usage:"retain cache directory for remote runbooks"`
-	Verbose         bool     `usage:"verbose"`
+	Debug                    bool     `usage:"debug"`
+	Long                     bool     `usage:"long format"`
+	FailFast                 bool     `usage:"fail fast"`
+	SkipTest                 bool     `usage:"skip \"test:\" section"`
+	SkipIncluded             bool     `usage:"skip running the included runbook by itself"`
+	RunMatch                 string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
+	RunID                    string   `usage:"run the matching runbook if there is only one runbook with a forward matching ID"`
+	GRPCNoTLS                bool     `usage:"disable TLS use in all gRPC runners"`
+	GRPCProtos               []string `usage:"set the name of proto source for all gRPC runners"`
+	GRPCImportPaths          []string `usage:"set the path to the directory where proto sources can be imported for all gRPC runners"`
+	GRPCProtosets            []string `usage:"set the path of protoset (FileDescriptorSet) for all gRPC runners"`
+	CaptureDir               string   `usage:"destination of runbook run capture results"`
+	ShareCookies             bool     `usage:"share cookies across all runbooks"`
+	CookieJar                string   `usage:"path to the file to load cookies from and save persistent cookies to"`
+	OpenAPICoverage          string   `usage:"output coverage of OpenAPI documents used by HTTP runners (\"table\",\"json\")"`
+	OpenAPICoverageThreshold float64  `usage:"if the coverage (%) of operations or responses is below this threshold, run command returns exit status 1 (EXIT_FAILURE)"`
+	Vars                     []string `usage:"set var to runbook (\"key:value\")"`
+	Runners                  []string `usage:"set runner to runbook (\"key:dsn\")"`
+	Overlays                 []string `usage:"overlay values on the runbook"`
+	Underlays                []string `usage:"lay values under the runbook"`
+	Sample                   int      `usage:"sample the specified number of runbooks"`
+	Shuffle                  string   `usage:"randomize the order of running runbooks (\"on\",\"off\",N)"`
+	Concurrent               string   `usage:"run runbooks concurrently (\"on\",\"off\",N)"`
+	ShardIndex               int      `usage:"index of distributed runbooks"`
+	ShardN                   int      `usage:"number of shards for distributing runbooks"`
+	Random                   int      `usage:"run the specified number of runbooks at random"`
+	Desc                     string   `usage:"description of runbook"`
+	Out                      string   `usage:"target path of runbook"`
+	Format                   string   `usage:"format of result output"`
+	AndRun                   bool     `usage:"run created runbook and capture the response for test"`
+	FromOpenAPI              string   `usage:"path or URL of the OpenAPI 3 document to create runbooks from"`
+	ByTag                    bool     `usage:"create a runbook per tag instead of per operation (with --from-openapi)"`
+	LoadTConcurrent          int      `usage:"number of concurrent load test runs"`
+	LoadTDuration            string   `usage:"load test running duration"`
+	LoadTWarmUp              string   `usage:"warn-up time for load test"`
+	LoadTThreshold           string   `usage:"if this threshold condition is not met, loadt command returns exit status 1 (EXIT_FAILURE)"`
+	LoadTMaxRPS              int      `usage:"max RunN per second for load test. 0 means unlimited."`
+	Profile                  bool     `usage:"profile runs of runbooks"`
+	ProfileOut               string   `usage:"profile output path"`
+	ProfileDepth             int      `usage:"depth of profile"`
+	ProfileUnit              string   `usage:"-"`
+	ProfileSort              string   `usage:"-"`
+	CacheDir                 string   `usage:"specify cache directory for remote runbooks"`
+	RetainCacheDir           bool     `usage:"retain cache directory for remote runbooks"`
+	Verbose                  bool     `usage:"verbose"`
 }
 
 func (f *Flags) ToOpts() ([]runn.Option, error) {
@@ -75,6 +82,7 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 		runn.GRPCNoTLS(f.GRPCNoTLS),
 		runn.GRPCProtos(f.GRPCProtos),
 		runn.GRPCImportPaths(f.GRPCImportPaths),
+		runn.GRPCProtosets(f.GRPCProtosets),
 		runn.Profile(f.Profile),
 	}
 	if f.RunID != "" {
@@ -118,6 +126,12 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 	if f.ShardN > 0 {
 		opts = append(opts, runn.RunShard(f.ShardN, f.ShardIndex))
 	}
+	if f.ShareCookies {
+		opts = append(opts, runn.ShareCookies(true))
+	}
+	if f.CookieJar != "" {
+		opts = append(opts, runn.CookieJarFile(f.CookieJar))
+	}
 
 	for _, v := range f.Vars {
 		splitted := strings.Split(v, keyValueSep)
@@ -7,13 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/bufbuild/protocompile"
-	"github.com/bufbuild/protocompile/linker"
 	"github.com/goccy/go-json"
 	"github.com/jhump/protoreflect/v2/grpcreflect"
 	"github.com/k1LoW/runn/version"
@@ -54,6 +55,7 @@ const (
 	grpcStoreTrailerKey  = "trailers"
 	grpcStoreMessageKey  = "message"
 	grpcStoreMessagesKey = "messages"
+	grpcStoreDetailsKey  = "details"
 	grpcStoreResponseKey = "res"
 )
 
@@ -67,10 +69,26 @@ type grpcRunner struct {
 	skipVerify  bool
 	importPaths []string
 	protos      []string
+	protosets   []string
+	bufImage    string
+	proxy       *runnerProxy
+	resolver    *hostResolver
 	cc          *grpc.ClientConn
 	refc        *grpcreflect.Client
 	mds         map[string]protoreflect.MethodDescriptor
 	operator    *operator
+	// protocol is the protocol to call RPCs ( grpc, grpc-web or connect )
+	protocol string
+	// codec is the codec of messages for gRPC-Web and Connect ( proto or json )
+	codec string
+	// httpClient is the HTTP client for gRPC-Web and Connect
+	httpClient *http.Client
+	// dialOpts are the additional dial options ( compression, keepalive, max message size, retry policy and authority )
+	dialOpts []grpc.DialOption
+	// serverName overrides the server name to verify the certificate and to send as SNI
+	serverName string
+	// perRPCCreds are the credentials attached to each RPC
+	perRPCCreds credentials.PerRPCCredentials
 }
 
 type grpcMessage struct {
@@ -84,6 +102,8 @@ type grpcRequest struct {
 	headers  metadata.MD
 	messages []*grpcMessage
 	timeout  time.Duration
+	// waitForReady blocks the RPC until the connection is ready instead of failing fast
+	waitForReady bool
 }
 
 func newGrpcRunner(name, target string) (*grpcRunner, error) {
@@ -94,7 +114,15 @@ func newGrpcRunner(name, target string) (*grpcRunner, error) {
 	}, nil
 }
 
+// isGrpcUnixTarget returns whether the target is a Unix domain socket ( `unix:///path/to.sock` or `unix:path/to.sock` ).
+func isGrpcUnixTarget(target string) bool {
+	return strings.HasPrefix(target, "unix:")
+}
+
 func (rnr *grpcRunner) Close() error {
+	if rnr.httpClient != nil {
+		rnr.httpClient.CloseIdleConnections()
+	}
 	if rnr.cc == nil {
 		rnr.refc = nil
 		return nil
@@ -103,56 +131,88 @@ func (rnr *grpcRunner) Close() error {
 	return rnr.cc.Close()
 }
 
+// useTLS returns whether to use TLS to connect to the target.
+func (rnr *grpcRunner) useTLS() bool {
+	if rnr.tls != nil {
+		return *rnr.tls
+	}
+	target := rnr.dialTarget()
+	return !strings.HasSuffix(target, ":80") && !isGrpcUnixTarget(target)
+}
+
+// tlsConfig returns the TLS config to connect to the target.
+func (rnr *grpcRunner) tlsConfig() (*tls.Config, error) {
+	tlsc := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: rnr.serverName}
+	if len(rnr.cert) != 0 {
+		certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
+		if err != nil {
+			return nil, err
+		}
+		tlsc.Certificates = []tls.Certificate{certificate}
+	}
+	if rnr.skipVerify {
+		//#nosec G402
+		tlsc.InsecureSkipVerify = true
+	} else if len(rnr.cacert) != 0 {
+		certpool, err := x509.SystemCertPool()
+		if err != nil {
+			// FIXME for Windows
+			// ref: https://github.com/golang/go/issues/18609
+			certpool = x509.NewCertPool()
+		}
+		if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
+			return nil, errors.New("failed to append cacert")
+		}
+		tlsc.RootCAs = certpool
+	}
+	return tlsc, nil
+}
+
 func (rnr *grpcRunner) Run(ctx context.Context, r *grpcRequest) error {
-	if rnr.cc == nil {
+	// gRPC-Web and Connect use the connection only for the server reflection
+	usesProtos := len(rnr.importPaths) > 0 || len(rnr.protos) > 0 || len(rnr.protosets) > 0 || rnr.bufImage != ""
+	if rnr.cc == nil && (!rnr.overHTTP() || !usesProtos) {
 		opts := []grpc.DialOption{
 			grpc.WithReturnConnectionError(),
 			grpc.WithUserAgent(fmt.Sprintf("runn/%s", version.Version)),
 		}
-		useTLS := true
-		if strings.HasSuffix(rnr.target, ":80") {
-			useTLS = false
+		opts = append(opts, rnr.dialOpts...)
+		if rnr.perRPCCreds != nil {
+			opts = append(opts, grpc.WithPerRPCCredentials(rnr.perRPCCreds))
 		}
-		if rnr.tls != nil {
-			useTLS = *rnr.tls
-		}
-		if useTLS {
-			tlsc := tls.Config{MinVersion: tls.VersionTLS12}
-			if len(rnr.cert) != 0 {
-				certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
-				if err != nil {
-					return err
-				}
-				tlsc.Certificates = []tls.Certificate{certificate}
-			}
-			if rnr.skipVerify {
-				//#nosec G402
-				tlsc.InsecureSkipVerify = true
-			} else if len(rnr.cacert) != 0 {
-				certpool, err := x509.SystemCertPool()
-				if err != nil {
-					// FIXME for Windows
-					// ref: https://github.com/golang/go/issues/18609
-					certpool = x509.NewCertPool()
-				}
-				if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
-					return errors.New("failed to append cacert")
-				}
-				tlsc.RootCAs = certpool
+		if rnr.useTLS() {
+			tlsc, err := rnr.tlsConfig()
+			if err != nil {
+				return err
 			}
-			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tlsc)))
+			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsc)))
 		} else {
 			opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 		}
+		if !isGrpcUnixTarget(rnr.target) {
+			switch {
+			case rnr.proxy != nil:
+				opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+					return rnr.proxy.dialContext(ctx, rnr.resolver.resolve(addr))
+				}))
+			case rnr.resolver != nil:
+				opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+					return rnr.resolver.dialContext(ctx, "tcp", addr)
+				}))
+			}
+		}
 		cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 		defer cancel()
-		cc, err := grpc.DialContext(cctx, rnr.target, opts...)
+		cc, err := grpc.DialContext(cctx, rnr.dialTarget(), opts...)
 		if err != nil {
+			if rnr.overHTTP() {
+				return rnr.reflectionOverHTTPError(err)
+			}
 			return err
 		}
 		rnr.cc = cc
 	}
-	if rnr.refc == nil {
+	if rnr.refc == nil && rnr.cc != nil {
 		rnr.refc = grpcreflect.NewClientAuto(ctx, rnr.cc)
 	}
 	if len(rnr.importPaths) > 0 || len(rnr.protos) > 0 {
@@ -160,16 +220,34 @@ func (rnr *grpcRunner) Run(ctx context.Context, r *grpcRequest) error {
 			return err
 		}
 	}
+	if len(rnr.protosets) > 0 || rnr.bufImage != "" {
+		if err := rnr.resolveAllMethodsUsingProtosets(); err != nil {
+			return err
+		}
+	}
+	key := strings.Join([]string{r.service, r.method}, "/")
 	if len(rnr.mds) == 0 {
+		if rnr.refc == nil {
+			return fmt.Errorf("cannot find method: %s", key)
+		}
 		if err := rnr.resolveAllMethodsUsingReflection(ctx); err != nil {
+			if rnr.overHTTP() {
+				return rnr.reflectionOverHTTPError(err)
+			}
 			return err
 		}
 	}
-	key := strings.Join([]string{r.service, r.method}, "/")
 	md, ok := rnr.mds[key]
 	if !ok {
 		return fmt.Errorf("cannot find method: %s", key)
 	}
+	if rnr.overHTTP() {
+		return rnr.invokeOverHTTP(ctx, md, r)
+	}
+	ctx, err := setHeaders(ctx, r.headers)
+	if err != nil {
+		return err
+	}
 	switch {
 	case !md.IsStreamingServer() && !md.IsStreamingClient():
 		rnr.operator.capturers.captureGRPCStart(rnr.name, GRPCUnary, r.service, r.method)
@@ -202,7 +280,6 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 		defer cancel()
 	}
 
-	ctx = setHeaders(ctx, r.headers)
 	req := dynamicpb.NewMessage(md.Input())
 
 	rnr.operator.capturers.captureGRPCRequestHeaders(r.headers)
@@ -216,7 +293,7 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 		resTrailers metadata.MD
 	)
 	res := dynamicpb.NewMessage(md.Output())
-	err := rnr.cc.Invoke(ctx, toEndpoint(md.FullName()), req, res, grpc.Header(&resHeaders), grpc.Trailer(&resTrailers))
+	err := rnr.cc.Invoke(ctx, toEndpoint(md.FullName()), req, res, append(r.callOptions(), grpc.Header(&resHeaders), grpc.Trailer(&resTrailers))...)
 	stat, ok := status.FromError(err)
 	if !ok {
 		return err
@@ -251,6 +328,7 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 		d[grpcStoreMessagesKey] = messages
 	} else {
 		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
 	}
 
 	rnr.operator.record(map[string]any{
@@ -269,7 +347,6 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		defer cancel()
 	}
 
-	ctx = setHeaders(ctx, r.headers)
 	req := dynamicpb.NewMessage(md.Input())
 
 	rnr.operator.capturers.captureGRPCRequestHeaders(r.headers)
@@ -284,7 +361,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		ClientStreams: md.IsStreamingClient(),
 	}
 
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()), r.callOptions()...)
 	if err != nil {
 		return err
 	}
@@ -334,6 +411,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 			messages = append(messages, msg)
 		} else {
 			d[grpcStoreMessageKey] = stat.Message()
+			d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
 		}
 	}
 	d[grpcStoreMessagesKey] = messages
@@ -361,8 +439,6 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		defer cancel()
 	}
 
-	ctx = setHeaders(ctx, r.headers)
-
 	rnr.operator.capturers.captureGRPCRequestHeaders(r.headers)
 
 	streamDesc := &grpc.StreamDesc{
@@ -370,7 +446,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		ServerStreams: md.IsStreamingServer(),
 		ClientStreams: md.IsStreamingClient(),
 	}
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()), r.callOptions()...)
 	if err != nil {
 		return err
 	}
@@ -430,6 +506,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		messages = append(messages, msg)
 	} else {
 		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
 	}
 
 	d[grpcStoreMessagesKey] = messages
@@ -455,7 +532,6 @@ func (rnr *grpcRunner) invokeBidiStreaming(ctx context.Context, md protoreflect.
 		return errors.New("unsupported timeout: for bidirectional streaming RPC")
 	}
 
-	ctx = setHeaders(ctx, r.headers)
 	rnr.operator.capturers.captureGRPCRequestHeaders(r.headers)
 
 	streamDesc := &grpc.StreamDesc{
@@ -464,7 +540,7 @@ func (rnr *grpcRunner) invokeBidiStreaming(ctx context.Context, md protoreflect.
 		ClientStreams: md.IsStreamingClient(),
 	}
 
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()), r.callOptions()...)
 	if err != nil {
 		return err
 	}
@@ -531,6 +607,7 @@ L:
 				messages = append(messages, msg)
 			} else {
 				d[grpcStoreMessageKey] = stat.Message()
+				d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
 			}
 		case GRPCOpClose:
 			clientClose = true
@@ -548,6 +625,7 @@ L:
 	if stat.Code() != codes.OK {
 		d[grpcStoreStatusKey] = int64(stat.Code())
 		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
 
 		rnr.operator.capturers.captureGRPCResponseStatus(stat)
 	}
@@ -603,6 +681,7 @@ L:
 					messages = append(messages, msg)
 				} else {
 					d[grpcStoreMessageKey] = stat.Message()
+					d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
 				}
 			}
 		}
@@ -634,14 +713,32 @@ L:
 	return nil
 }
 
-func setHeaders(ctx context.Context, h metadata.MD) context.Context {
+func (r *grpcRequest) callOptions() []grpc.CallOption {
+	var opts []grpc.CallOption
+	if r.waitForReady {
+		opts = append(opts, grpc.WaitForReady(true))
+	}
+	return opts
+}
+
+// setHeaders appends the headers to the outgoing metadata.
+// The values of binary headers ( `-bin` suffix ) are decoded from base64 because grpc-go encodes them on the wire.
+func setHeaders(ctx context.Context, h metadata.MD) (context.Context, error) {
 	var kv []string
 	for k, v := range h {
-		kv = append(kv, k)
-		kv = append(kv, v...)
+		for _, vv := range v {
+			if strings.HasSuffix(strings.ToLower(k), "-bin") {
+				b, err := decodeBinHeader(vv)
+				if err != nil {
+					return nil, fmt.Errorf("invalid binary header ( must be base64 encoded ): %s: %w", k, err)
+				}
+				vv = string(b)
+			}
+			kv = append(kv, k, vv)
+		}
 	}
 	ctx = metadata.AppendToOutgoingContext(ctx, kv...)
-	return ctx
+	return ctx, nil
 }
 
 func (rnr *grpcRunner) setMessage(req proto.Message, message map[string]any) error {
@@ -662,6 +759,11 @@ func (rnr *grpcRunner) setMessage(req proto.Message, message map[string]any) err
 	return protojson.Unmarshal(b, req)
 }
 
+// reflectionOverHTTPError returns the error when the methods of gRPC-Web or Connect cannot be resolved using the server reflection over native gRPC.
+func (rnr *grpcRunner) reflectionOverHTTPError(err error) error {
+	return fmt.Errorf("gRPC runner using %s protocol requires protos, protosets or bufImage if the server reflection over native gRPC is not available: %w", rnr.protocol, err)
+}
+
 func (rnr *grpcRunner) resolveAllMethodsUsingReflection(ctx context.Context) error {
 	svcs, err := rnr.refc.ListServices()
 	if err != nil {
@@ -749,7 +851,7 @@ func toEndpoint(mn protoreflect.FullName) string {
 	return fmt.Sprintf("/%s/%s", service, method)
 }
 
-func registerFiles(fds linker.Files) (err error) {
+func registerFiles[F protoreflect.FileDescriptor](fds []F) (err error) {
 	for _, fd := range fds {
 		// Skip registration of already registered descriptors
 		if _, err := protoregistry.GlobalFiles.FindFileByPath(fd.Path()); !errors.Is(protoregistry.NotFound, err) {
@@ -0,0 +1,216 @@
+package runn
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/k1LoW/duration"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/grpc/credentials"
+)
+
+const grpcDefaultJWTTTL = 1 * time.Hour
+
+var _ credentials.PerRPCCredentials = (*grpcPerRPCCredentials)(nil)
+
+// grpcPerRPCCredentials attaches the token of the token source to the `authorization` metadata of each RPC.
+type grpcPerRPCCredentials struct {
+	ts         oauth2.TokenSource
+	requireTLS bool
+}
+
+func (c *grpcPerRPCCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	t, err := c.ts.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token for per-RPC credentials: %w", err)
+	}
+	return map[string]string{
+		"authorization": fmt.Sprintf("%s %s", t.Type(), t.AccessToken),
+	}, nil
+}
+
+func (c *grpcPerRPCCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// newGrpcPerRPCCredentials returns the per-RPC credentials of the config.
+func newGrpcPerRPCCredentials(c *grpcCredentialsConfig) (*grpcPerRPCCredentials, error) {
+	n := 0
+	for _, set := range []bool{c.Bearer != nil, c.OAuth2 != nil, c.JWT != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return nil, errors.New("invalid credentials: only one of bearer, oauth2 or jwt must be set")
+	}
+	var ts oauth2.TokenSource
+	switch {
+	case c.Bearer != nil:
+		if c.Bearer.Token == "" {
+			return nil, errors.New("invalid credentials: bearer token is empty")
+		}
+		ts = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.Bearer.Token, TokenType: "Bearer"})
+	case c.OAuth2 != nil:
+		if c.OAuth2.TokenURL == "" || c.OAuth2.ClientID == "" {
+			return nil, errors.New("invalid credentials: oauth2 tokenURL and clientID are required")
+		}
+		cc := &clientcredentials.Config{
+			ClientID:     c.OAuth2.ClientID,
+			ClientSecret: c.OAuth2.ClientSecret,
+			TokenURL:     c.OAuth2.TokenURL,
+			Scopes:       c.OAuth2.Scopes,
+		}
+		if len(c.OAuth2.EndpointParams) > 0 {
+			cc.EndpointParams = url.Values{}
+			for k, v := range c.OAuth2.EndpointParams {
+				cc.EndpointParams.Set(k, v)
+			}
+		}
+		// The token is cached and refreshed when it expires
+		ts = cc.TokenSource(context.Background())
+	case c.JWT != nil:
+		js, err := newJWTTokenSource(c.JWT)
+		if err != nil {
+			return nil, err
+		}
+		ts = oauth2.ReuseTokenSource(nil, js)
+	}
+	return &grpcPerRPCCredentials{
+		ts:         ts,
+		requireTLS: !c.Insecure,
+	}, nil
+}
+
+// jwtTokenSource is the token source that issues the JWT signed with the local key.
+type jwtTokenSource struct {
+	c      *grpcJWTConfig
+	method jwt.SigningMethod
+	key    any
+	ttl    time.Duration
+}
+
+func newJWTTokenSource(c *grpcJWTConfig) (*jwtTokenSource, error) {
+	b := c.key
+	if len(b) == 0 {
+		if c.Key == "" {
+			return nil, errors.New("invalid credentials: jwt key is required")
+		}
+		var err error
+		b, err = readFile(c.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	method, key, err := parseJWTSigningKey(c.Algorithm, b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials: jwt key: %w", err)
+	}
+	ttl := grpcDefaultJWTTTL
+	if c.TTL != "" {
+		ttl, err = duration.Parse(c.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credentials: jwt ttl: %w", err)
+		}
+	}
+	return &jwtTokenSource{
+		c:      c,
+		method: method,
+		key:    key,
+		ttl:    ttl,
+	}, nil
+}
+
+func (s *jwtTokenSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+	exp := now.Add(s.ttl)
+	claims := jwt.MapClaims{}
+	for k, v := range s.c.Claims {
+		claims[k] = v
+	}
+	if s.c.Issuer != "" {
+		claims["iss"] = s.c.Issuer
+	}
+	if s.c.Subject != "" {
+		claims["sub"] = s.c.Subject
+	}
+	switch len(s.c.Audience) {
+	case 0:
+	case 1:
+		claims["aud"] = s.c.Audience[0]
+	default:
+		claims["aud"] = s.c.Audience
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = exp.Unix()
+	t := jwt.NewWithClaims(s.method, claims)
+	if s.c.KeyID != "" {
+		t.Header["kid"] = s.c.KeyID
+	}
+	signed, err := t.SignedString(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{
+		AccessToken: signed,
+		TokenType:   "Bearer",
+		Expiry:      exp,
+	}, nil
+}
+
+// parseJWTSigningKey parses the key for the algorithm.
+// If the algorithm is not set, it is detected from the key ( RS256 for RSA, ES256/ES384/ES512 for ECDSA, EdDSA for Ed25519 and HS256 for the key that is not PEM encoded ).
+func parseJWTSigningKey(alg string, b []byte) (jwt.SigningMethod, any, error) {
+	if alg == "" {
+		if blk, _ := pem.Decode(b); blk == nil {
+			return jwt.SigningMethodHS256, b, nil
+		}
+		if k, err := jwt.ParseRSAPrivateKeyFromPEM(b); err == nil {
+			return jwt.SigningMethodRS256, k, nil
+		}
+		if k, err := jwt.ParseECPrivateKeyFromPEM(b); err == nil {
+			switch k.Curve.Params().BitSize {
+			case 384:
+				return jwt.SigningMethodES384, k, nil
+			case 521:
+				return jwt.SigningMethodES512, k, nil
+			default:
+				return jwt.SigningMethodES256, k, nil
+			}
+		}
+		if k, err := jwt.ParseEdPrivateKeyFromPEM(b); err == nil {
+			return jwt.SigningMethodEdDSA, k, nil
+		}
+		return nil, nil, errors.New("unsupported private key")
+	}
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, nil, fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+	var (
+		key any
+		err error
+	)
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		key = b
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(b)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPrivateKeyFromPEM(b)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(b)
+	default:
+		return nil, nil, fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return method, key, nil
+}
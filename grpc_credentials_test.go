@@ -0,0 +1,260 @@
+package runn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNewGrpcPerRPCCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      *grpcCredentialsConfig
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			"bearer",
+			&grpcCredentialsConfig{Bearer: &grpcBearerConfig{Token: "xxxxx"}},
+			map[string]string{"authorization": "Bearer xxxxx"},
+			false,
+		},
+		{"empty bearer token", &grpcCredentialsConfig{Bearer: &grpcBearerConfig{}}, nil, true},
+		{"no credentials", &grpcCredentialsConfig{Insecure: true}, nil, true},
+		{
+			"multiple credentials",
+			&grpcCredentialsConfig{Bearer: &grpcBearerConfig{Token: "xxxxx"}, JWT: &grpcJWTConfig{key: []byte("secret")}},
+			nil,
+			true,
+		},
+		{"oauth2 without tokenURL", &grpcCredentialsConfig{OAuth2: &grpcOAuth2Config{ClientID: "runn"}}, nil, true},
+		{"jwt without key", &grpcCredentialsConfig{JWT: &grpcJWTConfig{}}, nil, true},
+		{"jwt with unsupported algorithm", &grpcCredentialsConfig{JWT: &grpcJWTConfig{Algorithm: "none", key: []byte("secret")}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newGrpcPerRPCCredentials(tt.in)
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if !c.RequireTransportSecurity() {
+				t.Error("want to require transport security")
+			}
+			got, err := c.GetRequestMetadata(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGrpcOAuth2Credentials(t *testing.T) {
+	issued := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if got := r.PostForm.Get("grant_type"); got != "client_credentials" {
+			t.Errorf("got %v\nwant %v", got, "client_credentials")
+		}
+		if got := r.PostForm.Get("audience"); got != "api" {
+			t.Errorf("got %v\nwant %v", got, "api")
+		}
+		issued++
+		w.Header().Set("Content-Type", MediaTypeApplicationJSON)
+		_, _ = fmt.Fprintf(w, `{"access_token":"token%d","token_type":"Bearer","expires_in":3600}`, issued)
+	}))
+	t.Cleanup(ts.Close)
+
+	c, err := newGrpcPerRPCCredentials(&grpcCredentialsConfig{
+		OAuth2: &grpcOAuth2Config{
+			TokenURL:       ts.URL,
+			ClientID:       "runn",
+			ClientSecret:   "secret",
+			Scopes:         []string{"read"},
+			EndpointParams: map[string]string{"audience": "api"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := c.GetRequestMetadata(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The token is reused until it expires
+		if want := "Bearer token1"; got["authorization"] != want {
+			t.Errorf("got %v\nwant %v", got["authorization"], want)
+		}
+	}
+}
+
+func TestGrpcJWTCredentials(t *testing.T) {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	tests := []struct {
+		name      string
+		in        *grpcJWTConfig
+		verifyKey any
+		wantAlg   string
+	}{
+		{
+			"hmac secret",
+			&grpcJWTConfig{Issuer: "runn", Subject: "alice", Audience: []string{"api"}, key: []byte("secret")},
+			[]byte("secret"),
+			"HS256",
+		},
+		{
+			"ecdsa key",
+			&grpcJWTConfig{Issuer: "runn", Subject: "alice", Audience: []string{"api"}, KeyID: "key1", Claims: map[string]any{"scope": "read"}, TTL: "10min", key: ecKey},
+			&pk.PublicKey,
+			"ES256",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newGrpcPerRPCCredentials(&grpcCredentialsConfig{JWT: tt.in})
+			if err != nil {
+				t.Fatal(err)
+			}
+			md, err := c.GetRequestMetadata(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw := strings.TrimPrefix(md["authorization"], "Bearer ")
+			claims := jwt.MapClaims{}
+			token, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+				return tt.verifyKey, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := token.Method.Alg(); got != tt.wantAlg {
+				t.Errorf("got %v\nwant %v", got, tt.wantAlg)
+			}
+			if got := token.Header["kid"]; tt.in.KeyID != "" && got != tt.in.KeyID {
+				t.Errorf("got %v\nwant %v", got, tt.in.KeyID)
+			}
+			if !claims.VerifyIssuer("runn", true) || !claims.VerifyAudience("api", true) || claims["sub"] != "alice" {
+				t.Errorf("invalid claims: %v", claims)
+			}
+			for k, v := range tt.in.Claims {
+				if claims[k] != v {
+					t.Errorf("got %v\nwant %v", claims[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestGrpcRunnerPerRPCCredentialsAndBinaryHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		insecure bool
+		headers  metadata.MD
+		want     metadata.MD
+		wantErr  bool
+	}{
+		{
+			"credentials and binary headers",
+			true,
+			metadata.MD{"data-bin": {base64.StdEncoding.EncodeToString([]byte{0x00, 0x01, 0xff})}},
+			metadata.MD{"authorization": {"Bearer xxxxx"}, "data-bin": {string([]byte{0x00, 0x01, 0xff})}},
+			false,
+		},
+		{
+			"unpadded binary headers",
+			true,
+			metadata.MD{"data-bin": {base64.RawStdEncoding.EncodeToString([]byte("hello"))}},
+			metadata.MD{"authorization": {"Bearer xxxxx"}, "data-bin": {"hello"}},
+			false,
+		},
+		{"invalid binary headers", true, metadata.MD{"data-bin": {"!!!"}}, nil, true},
+		{"credentials require TLS", false, metadata.MD{}, nil, true},
+	}
+	ctx := context.Background()
+	useTLS := false
+	ts := testutil.GRPCServer(t, useTLS, false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &grpcRunnerConfig{}
+			for _, opt := range []grpcRunnerOption{GRPCBearerCredentials("xxxxx"), GRPCInsecureCredentials(tt.insecure)} {
+				if err := opt(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			creds, err := newGrpcPerRPCCredentials(c.Credentials)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newGrpcRunner("greq", ts.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			r.tls = &useTLS
+			r.perRPCCreds = creds
+			r.protos = []string{filepath.Join(testutil.Testdata(), "grpctest.proto")}
+			req := &grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "Hello",
+				headers: tt.headers,
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+				},
+			}
+			if err := r.Run(ctx, req); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			latest := ts.Requests()[len(ts.Requests())-1]
+			for k, want := range tt.want {
+				if diff := cmp.Diff(latest.Headers.Get(k), want); diff != "" {
+					t.Errorf("%s: %s", k, diff)
+				}
+			}
+		})
+	}
+}
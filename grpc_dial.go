@@ -0,0 +1,172 @@
+package runn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/duration"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	grpcDefaultInitialBackoff    = 100 * time.Millisecond
+	grpcDefaultMaxBackoff        = 1 * time.Second
+	grpcDefaultBackoffMultiplier = 2.0
+)
+
+// newGrpcDialOptions returns the dial options of the gRPC runner config.
+func newGrpcDialOptions(c *grpcRunnerConfig) ([]grpc.DialOption, error) {
+	var (
+		opts     []grpc.DialOption
+		callOpts []grpc.CallOption
+	)
+	switch c.Compression {
+	case "":
+	case gzip.Name:
+		callOpts = append(callOpts, grpc.UseCompressor(gzip.Name))
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", c.Compression)
+	}
+	if c.MaxRecvMsgSize < 0 {
+		return nil, fmt.Errorf("invalid maxRecvMsgSize: %d", c.MaxRecvMsgSize)
+	}
+	if c.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(c.MaxRecvMsgSize))
+	}
+	if c.MaxSendMsgSize < 0 {
+		return nil, fmt.Errorf("invalid maxSendMsgSize: %d", c.MaxSendMsgSize)
+	}
+	if c.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(c.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	if c.Keepalive != nil {
+		kp := keepalive.ClientParameters{
+			PermitWithoutStream: c.Keepalive.PermitWithoutStream,
+		}
+		var err error
+		if c.Keepalive.Time != "" {
+			kp.Time, err = duration.Parse(c.Keepalive.Time)
+			if err != nil {
+				return nil, fmt.Errorf("invalid keepalive time: %w", err)
+			}
+		}
+		if c.Keepalive.Timeout != "" {
+			kp.Timeout, err = duration.Parse(c.Keepalive.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid keepalive timeout: %w", err)
+			}
+		}
+		opts = append(opts, grpc.WithKeepaliveParams(kp))
+	}
+	if c.RetryPolicy != nil {
+		sc, err := grpcRetryServiceConfig(c.RetryPolicy)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithDefaultServiceConfig(sc))
+	}
+	if c.Authority != "" {
+		opts = append(opts, grpc.WithAuthority(c.Authority))
+	}
+	return opts, nil
+}
+
+// grpcRetryServiceConfig returns the service config ( JSON ) applying the retry policy to all methods.
+func grpcRetryServiceConfig(p *grpcRetryPolicyConfig) (string, error) {
+	if p.MaxAttempts < 2 {
+		return "", fmt.Errorf("invalid retryPolicy: maxAttempts must be greater than 1: %d", p.MaxAttempts)
+	}
+	initialBackoff := grpcDefaultInitialBackoff
+	if p.InitialBackoff != "" {
+		d, err := duration.Parse(p.InitialBackoff)
+		if err != nil {
+			return "", fmt.Errorf("invalid retryPolicy: initialBackoff: %w", err)
+		}
+		initialBackoff = d
+	}
+	maxBackoff := grpcDefaultMaxBackoff
+	if p.MaxBackoff != "" {
+		d, err := duration.Parse(p.MaxBackoff)
+		if err != nil {
+			return "", fmt.Errorf("invalid retryPolicy: maxBackoff: %w", err)
+		}
+		maxBackoff = d
+	}
+	if initialBackoff <= 0 || maxBackoff <= 0 {
+		return "", fmt.Errorf("invalid retryPolicy: backoff must be greater than 0: initialBackoff: %s, maxBackoff: %s", initialBackoff, maxBackoff)
+	}
+	multiplier := grpcDefaultBackoffMultiplier
+	if p.BackoffMultiplier != 0 {
+		multiplier = p.BackoffMultiplier
+	}
+	if multiplier <= 0 {
+		return "", fmt.Errorf("invalid retryPolicy: backoffMultiplier must be greater than 0: %v", multiplier)
+	}
+	retryable := p.RetryableStatusCodes
+	if len(retryable) == 0 {
+		retryable = []string{codes.Unavailable.String()}
+	}
+	var statusCodes []string
+	for _, s := range retryable {
+		// Accept both the name ( UNAVAILABLE ) and the number ( 14 )
+		var code codes.Code
+		v := strings.ToUpper(strings.TrimSpace(s))
+		if _, err := strconv.Atoi(v); err != nil {
+			v = strconv.Quote(v)
+		}
+		if err := code.UnmarshalJSON([]byte(v)); err != nil {
+			return "", fmt.Errorf("invalid retryPolicy: retryableStatusCodes: %w", err)
+		}
+		statusCodes = append(statusCodes, grpcStatusCodeName(code))
+	}
+	sc := map[string]any{
+		"methodConfig": []any{
+			map[string]any{
+				"name": []any{map[string]any{}},
+				"retryPolicy": map[string]any{
+					"maxAttempts":          p.MaxAttempts,
+					"initialBackoff":       grpcServiceConfigDuration(initialBackoff),
+					"maxBackoff":           grpcServiceConfigDuration(maxBackoff),
+					"backoffMultiplier":    multiplier,
+					"retryableStatusCodes": statusCodes,
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// grpcStatusCodeName returns the name of the status code used in the service config ( e.g. UNAVAILABLE ).
+func grpcStatusCodeName(c codes.Code) string {
+	// codes.Code.String() returns CamelCase ( e.g. Unavailable, DeadlineExceeded )
+	var (
+		b    strings.Builder
+		prev rune
+	)
+	for _, r := range c.String() {
+		if prev >= 'a' && prev <= 'z' && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return strings.ToUpper(b.String())
+}
+
+// grpcServiceConfigDuration returns the duration in the format of the service config ( e.g. 0.1s ).
+func grpcServiceConfigDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
@@ -0,0 +1,166 @@
+package runn
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGrpcRetryServiceConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      *grpcRetryPolicyConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			"default",
+			&grpcRetryPolicyConfig{MaxAttempts: 3},
+			`{"methodConfig":[{"name":[{}],"retryPolicy":{"backoffMultiplier":2,"initialBackoff":"0.1s","maxAttempts":3,"maxBackoff":"1s","retryableStatusCodes":["UNAVAILABLE"]}}]}`,
+			false,
+		},
+		{
+			"all fields",
+			&grpcRetryPolicyConfig{
+				MaxAttempts:          4,
+				InitialBackoff:       "50ms",
+				MaxBackoff:           "2sec",
+				BackoffMultiplier:    1.5,
+				RetryableStatusCodes: []string{"unavailable", "DEADLINE_EXCEEDED", "8"},
+			},
+			`{"methodConfig":[{"name":[{}],"retryPolicy":{"backoffMultiplier":1.5,"initialBackoff":"0.05s","maxAttempts":4,"maxBackoff":"2s","retryableStatusCodes":["UNAVAILABLE","DEADLINE_EXCEEDED","RESOURCE_EXHAUSTED"]}}]}`,
+			false,
+		},
+		{"maxAttempts is required", &grpcRetryPolicyConfig{}, "", true},
+		{"invalid backoff", &grpcRetryPolicyConfig{MaxAttempts: 2, InitialBackoff: "fast"}, "", true},
+		{"invalid multiplier", &grpcRetryPolicyConfig{MaxAttempts: 2, BackoffMultiplier: -1}, "", true},
+		{"invalid status code", &grpcRetryPolicyConfig{MaxAttempts: 2, RetryableStatusCodes: []string{"NOT_EXIST"}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := grpcRetryServiceConfig(tt.in)
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGrpcDialOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       *grpcRunnerConfig
+		wantOpts int
+		wantErr  bool
+	}{
+		{"no options", &grpcRunnerConfig{}, 0, false},
+		{"call options", &grpcRunnerConfig{Compression: "gzip", MaxRecvMsgSize: 10, MaxSendMsgSize: 10}, 1, false},
+		{"keepalive", &grpcRunnerConfig{Keepalive: &grpcKeepaliveConfig{Time: "10sec", Timeout: "1sec"}}, 1, false},
+		{"retry policy and authority", &grpcRunnerConfig{RetryPolicy: &grpcRetryPolicyConfig{MaxAttempts: 2}, Authority: "example.com"}, 2, false},
+		{"unsupported compression", &grpcRunnerConfig{Compression: "br"}, 0, true},
+		{"invalid max message size", &grpcRunnerConfig{MaxRecvMsgSize: -1}, 0, true},
+		{"invalid keepalive", &grpcRunnerConfig{Keepalive: &grpcKeepaliveConfig{Time: "often"}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newGrpcDialOptions(tt.in)
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if len(got) != tt.wantOpts {
+				t.Errorf("got %v\nwant %v", len(got), tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestGrpcRunnerDialOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []grpcRunnerOption
+		wantStatus int
+	}{
+		{"compression", []grpcRunnerOption{GRPCCompression("gzip")}, int(codes.OK)},
+		{"keepalive and retry policy", []grpcRunnerOption{GRPCKeepalive("10sec", "1sec", true), GRPCRetryPolicy(3, "10ms", "100ms", 2, []string{"UNAVAILABLE"})}, int(codes.OK)},
+		{"authority", []grpcRunnerOption{GRPCAuthority("grpc.example.com")}, int(codes.OK)},
+		{"exceed max receive message size", []grpcRunnerOption{GRPCMaxRecvMsgSize(10)}, int(codes.ResourceExhausted)},
+	}
+	ctx := context.Background()
+	useTLS := false
+	ts := testutil.GRPCServer(t, useTLS, false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &grpcRunnerConfig{}
+			for _, opt := range tt.opts {
+				if err := opt(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dopts, err := newGrpcDialOptions(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newGrpcRunner("greq", ts.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			r.tls = &useTLS
+			r.dialOpts = dopts
+			// The limit of the message size is also applied to the server reflection
+			r.protos = []string{filepath.Join(testutil.Testdata(), "grpctest.proto")}
+			req := &grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "Hello",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+				},
+				waitForReady: true,
+			}
+			if err := r.Run(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			if diff := cmp.Diff(res["status"], tt.wantStatus); diff != "" {
+				t.Error(diff)
+			}
+			if tt.wantStatus != int(codes.OK) {
+				return
+			}
+			latest := ts.Requests()[len(ts.Requests())-1]
+			if c.Authority != "" {
+				if got := latest.Headers.Get(":authority"); len(got) == 0 || got[0] != c.Authority {
+					t.Errorf("got %v\nwant %v", got, c.Authority)
+				}
+			}
+		})
+	}
+}
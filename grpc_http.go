@@ -0,0 +1,658 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/version"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	GRPCProtocolGRPC    = "grpc"
+	GRPCProtocolGRPCWeb = "grpc-web"
+	GRPCProtocolConnect = "connect"
+)
+
+const (
+	GRPCCodecProto = "proto"
+	GRPCCodecJSON  = "json"
+)
+
+const (
+	// Flags of the envelope ( length-prefixed message )
+	grpcHTTPFlagCompressed = 0b00000001
+	grpcHTTPFlagEndStream  = 0b00000010 // Connect
+	grpcHTTPFlagTrailer    = 0b10000000 // gRPC-Web
+
+	grpcHTTPEnvelopePrefixLen = 5
+)
+
+// connectCodes are the codes of Connect errors.
+var connectCodes = map[string]codes.Code{
+	"canceled":            codes.Canceled,
+	"unknown":             codes.Unknown,
+	"invalid_argument":    codes.InvalidArgument,
+	"deadline_exceeded":   codes.DeadlineExceeded,
+	"not_found":           codes.NotFound,
+	"already_exists":      codes.AlreadyExists,
+	"permission_denied":   codes.PermissionDenied,
+	"resource_exhausted":  codes.ResourceExhausted,
+	"failed_precondition": codes.FailedPrecondition,
+	"aborted":             codes.Aborted,
+	"out_of_range":        codes.OutOfRange,
+	"unimplemented":       codes.Unimplemented,
+	"internal":            codes.Internal,
+	"unavailable":         codes.Unavailable,
+	"data_loss":           codes.DataLoss,
+	"unauthenticated":     codes.Unauthenticated,
+}
+
+// connectError is the error of Connect protocol.
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	Details []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"details,omitempty"`
+}
+
+// connectEndStream is the end-stream message of Connect streaming RPC.
+type connectEndStream struct {
+	Error    *connectError       `json:"error,omitempty"`
+	Metadata map[string][]string `json:"metadata,omitempty"`
+}
+
+func validateGRPCProtocol(protocol, codec string) error {
+	switch protocol {
+	case "", GRPCProtocolGRPC, GRPCProtocolGRPCWeb, GRPCProtocolConnect:
+	default:
+		return fmt.Errorf("invalid gRPC protocol: %s", protocol)
+	}
+	switch codec {
+	case "", GRPCCodecProto, GRPCCodecJSON:
+	default:
+		return fmt.Errorf("invalid gRPC codec: %s", codec)
+	}
+	if codec != "" && (protocol == "" || protocol == GRPCProtocolGRPC) {
+		return fmt.Errorf("codec can be set only for %s or %s protocol", GRPCProtocolGRPCWeb, GRPCProtocolConnect)
+	}
+	return nil
+}
+
+// validateGRPCOverHTTPOptions validates that the options only for native gRPC are not set for gRPC-Web or Connect.
+func validateGRPCOverHTTPOptions(c *grpcRunnerConfig) error {
+	if c.Protocol != GRPCProtocolGRPCWeb && c.Protocol != GRPCProtocolConnect {
+		return nil
+	}
+	var opts []string
+	if c.Compression != "" {
+		opts = append(opts, "compression")
+	}
+	if c.Keepalive != nil {
+		opts = append(opts, "keepalive")
+	}
+	if c.MaxRecvMsgSize != 0 {
+		opts = append(opts, "maxRecvMsgSize")
+	}
+	if c.MaxSendMsgSize != 0 {
+		opts = append(opts, "maxSendMsgSize")
+	}
+	if c.RetryPolicy != nil {
+		opts = append(opts, "retryPolicy")
+	}
+	if c.Authority != "" {
+		opts = append(opts, "authority")
+	}
+	if len(opts) > 0 {
+		return fmt.Errorf("%s cannot be used with %s protocol", strings.Join(opts, ", "), c.Protocol)
+	}
+	return nil
+}
+
+// overHTTP returns whether RPCs are called over HTTP ( gRPC-Web or Connect ) instead of native gRPC.
+func (rnr *grpcRunner) overHTTP() bool {
+	return rnr.protocol == GRPCProtocolGRPCWeb || rnr.protocol == GRPCProtocolConnect
+}
+
+// dialTarget returns the target to dial. For gRPC-Web and Connect, the target can be URL.
+func (rnr *grpcRunner) dialTarget() string {
+	u, err := url.Parse(rnr.target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return rnr.target
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "http" {
+		return u.Host + ":80"
+	}
+	return u.Host + ":443"
+}
+
+// baseURL returns the base URL of gRPC-Web and Connect.
+func (rnr *grpcRunner) baseURL() (*url.URL, error) {
+	if strings.HasPrefix(rnr.target, "http://") || strings.HasPrefix(rnr.target, "https://") {
+		return url.Parse(strings.TrimSuffix(rnr.target, "/"))
+	}
+	scheme := "http"
+	if rnr.useTLS() {
+		scheme = "https"
+	}
+	return url.Parse(fmt.Sprintf("%s://%s", scheme, rnr.target))
+}
+
+func (rnr *grpcRunner) grpcHTTPClient() (*http.Client, error) {
+	if rnr.httpClient != nil {
+		return rnr.httpClient, nil
+	}
+	ts := http.DefaultTransport.(*http.Transport).Clone()
+	tlsc, err := rnr.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	ts.TLSClientConfig = tlsc
+	if rnr.proxy != nil {
+		ts.Proxy = rnr.proxy.httpProxyFunc()
+	}
+	switch {
+	case rnr.proxy != nil && rnr.resolver != nil:
+		// Dial via the proxy with CONNECT to the overridden address as with native gRPC
+		ts.Proxy = nil
+		ts.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return rnr.proxy.dialContext(ctx, rnr.resolver.resolve(addr))
+		}
+	case rnr.resolver != nil:
+		ts.DialContext = rnr.resolver.dialContext
+	}
+	rnr.httpClient = &http.Client{Transport: ts}
+	return rnr.httpClient, nil
+}
+
+// invokeOverHTTP calls the RPC using gRPC-Web or Connect protocol.
+// Streaming RPCs are half-duplex; all request messages are sent before receiving response messages.
+func (rnr *grpcRunner) invokeOverHTTP(ctx context.Context, md protoreflect.MethodDescriptor, r *grpcRequest) error {
+	var typ GRPCType
+	switch {
+	case !md.IsStreamingServer() && !md.IsStreamingClient():
+		typ = GRPCUnary
+	case md.IsStreamingServer() && !md.IsStreamingClient():
+		typ = GRPCServerStreaming
+	case !md.IsStreamingServer() && md.IsStreamingClient():
+		typ = GRPCClientStreaming
+	default:
+		return fmt.Errorf("bidirectional streaming RPC is not supported with %s protocol", rnr.protocol)
+	}
+	switch typ {
+	case GRPCUnary:
+		if len(r.messages) != 1 {
+			return errors.New("unary RPC message should be 1")
+		}
+	case GRPCServerStreaming:
+		if len(r.messages) != 1 {
+			return errors.New("server streaming RPC message should be 1")
+		}
+	}
+	rnr.operator.capturers.captureGRPCStart(rnr.name, typ, r.service, r.method)
+	defer rnr.operator.capturers.captureGRPCEnd(rnr.name, typ, r.service, r.method)
+
+	if r.timeout > 0 {
+		cctx, cancel := context.WithTimeout(ctx, r.timeout)
+		ctx = cctx
+		defer cancel()
+	}
+	rnr.operator.capturers.captureGRPCRequestHeaders(r.headers)
+
+	var reqMsgs [][]byte
+	for _, m := range r.messages {
+		if m.op != GRPCOpMessage {
+			return fmt.Errorf("invalid op: %v", m.op)
+		}
+		req := dynamicpb.NewMessage(md.Input())
+		if err := rnr.setMessage(req, m.params); err != nil {
+			return err
+		}
+		b, err := rnr.marshalMessage(req)
+		if err != nil {
+			return err
+		}
+		reqMsgs = append(reqMsgs, b)
+	}
+
+	u, err := rnr.baseURL()
+	if err != nil {
+		return err
+	}
+	u = u.JoinPath(r.service, r.method)
+	// Connect unary RPC sends the message without the envelope
+	enveloped := rnr.protocol == GRPCProtocolGRPCWeb || typ != GRPCUnary
+	body := new(bytes.Buffer)
+	for _, b := range reqMsgs {
+		if enveloped {
+			writeGRPCHTTPEnvelope(body, 0, b)
+		} else {
+			body.Write(b)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return err
+	}
+	for k, v := range r.headers {
+		for _, vv := range v {
+			req.Header.Add(k, vv)
+		}
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("runn/%s", version.Version))
+	req.Header.Set("Content-Type", rnr.contentType(typ))
+	switch rnr.protocol {
+	case GRPCProtocolGRPCWeb:
+		req.Header.Set("X-Grpc-Web", "1")
+		if r.timeout > 0 {
+			req.Header.Set("Grpc-Timeout", fmt.Sprintf("%dm", r.timeout.Milliseconds()))
+		}
+	case GRPCProtocolConnect:
+		req.Header.Set("Connect-Protocol-Version", "1")
+		if r.timeout > 0 {
+			req.Header.Set("Connect-Timeout-Ms", strconv.FormatInt(r.timeout.Milliseconds(), 10))
+		}
+	}
+	if rnr.perRPCCreds != nil {
+		if rnr.perRPCCreds.RequireTransportSecurity() && u.Scheme != "https" {
+			return errors.New("credentials require TLS: set insecure: true to send credentials without TLS")
+		}
+		md, err := rnr.perRPCCreds.GetRequestMetadata(ctx, u.String())
+		if err != nil {
+			return err
+		}
+		for k, v := range md {
+			req.Header.Set(k, v)
+		}
+	}
+
+	client, err := rnr.grpcHTTPClient()
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var (
+		resMsgs  [][]byte
+		trailers metadata.MD
+		stat     *status.Status
+	)
+	headers := grpcHTTPHeaderToMD(res.Header)
+	switch {
+	case rnr.protocol == GRPCProtocolGRPCWeb:
+		resMsgs, trailers, stat, err = readGRPCWebResponse(res)
+	case typ == GRPCUnary:
+		resMsgs, trailers, stat, err = readConnectUnaryResponse(res)
+		// Trailers of Connect unary RPC are sent as headers with the prefix `Trailer-`
+		for k := range headers {
+			if strings.HasPrefix(k, "trailer-") {
+				delete(headers, k)
+			}
+		}
+	default:
+		resMsgs, trailers, stat, err = readConnectStreamResponse(res)
+	}
+	if err != nil {
+		return err
+	}
+
+	rnr.operator.capturers.captureGRPCResponseStatus(stat)
+	rnr.operator.capturers.captureGRPCResponseHeaders(headers)
+
+	d := map[string]any{
+		string(grpcStoreHeaderKey):  headers,
+		string(grpcStoreTrailerKey): trailers,
+		string(grpcStoreMessageKey): nil,
+	}
+	if typ == GRPCUnary {
+		d[grpcStoreStatusKey] = int(stat.Code())
+	} else {
+		d[grpcStoreStatusKey] = int64(stat.Code())
+	}
+	var messages []map[string]any
+	for _, b := range resMsgs {
+		res := dynamicpb.NewMessage(md.Output())
+		if err := rnr.unmarshalMessage(b, res); err != nil {
+			return err
+		}
+		msg, err := grpcMessageToMap(res)
+		if err != nil {
+			return err
+		}
+		d[grpcStoreMessageKey] = msg
+
+		rnr.operator.capturers.captureGRPCResponseMessage(msg)
+
+		messages = append(messages, msg)
+	}
+	if stat.Code() != codes.OK {
+		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = grpcStatusDetails(stat)
+	}
+	if typ != GRPCUnary || stat.Code() == codes.OK {
+		d[grpcStoreMessagesKey] = messages
+	}
+
+	rnr.operator.capturers.captureGRPCResponseTrailers(trailers)
+
+	rnr.operator.record(map[string]any{
+		string(grpcStoreResponseKey): d,
+	})
+	return nil
+}
+
+func (rnr *grpcRunner) contentType(typ GRPCType) string {
+	codec := rnr.codec
+	if codec == "" {
+		codec = GRPCCodecProto
+	}
+	switch {
+	case rnr.protocol == GRPCProtocolGRPCWeb:
+		return fmt.Sprintf("application/grpc-web+%s", codec)
+	case typ == GRPCUnary:
+		return fmt.Sprintf("application/%s", codec)
+	default:
+		return fmt.Sprintf("application/connect+%s", codec)
+	}
+}
+
+func (rnr *grpcRunner) marshalMessage(m proto.Message) ([]byte, error) {
+	if rnr.codec == GRPCCodecJSON {
+		return protojson.Marshal(m)
+	}
+	return proto.Marshal(m)
+}
+
+func (rnr *grpcRunner) unmarshalMessage(b []byte, m proto.Message) error {
+	if rnr.codec == GRPCCodecJSON {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+	}
+	return proto.Unmarshal(b, m)
+}
+
+func grpcMessageToMap(m proto.Message) (map[string]any, error) {
+	b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeGRPCHTTPEnvelope(w *bytes.Buffer, flags byte, b []byte) {
+	prefix := make([]byte, grpcHTTPEnvelopePrefixLen)
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(b)))
+	w.Write(prefix)
+	w.Write(b)
+}
+
+// readGRPCHTTPEnvelope reads the envelope ( length-prefixed message ). It returns io.EOF if there are no more envelopes.
+func readGRPCHTTPEnvelope(r io.Reader) (byte, []byte, error) {
+	prefix := make([]byte, grpcHTTPEnvelopePrefixLen)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errors.New("invalid envelope: unexpected EOF")
+		}
+		return 0, nil, err
+	}
+	flags := prefix[0]
+	if flags&grpcHTTPFlagCompressed != 0 {
+		return 0, nil, errors.New("compressed message is not supported")
+	}
+	b := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	return flags, b, nil
+}
+
+// readGRPCWebResponse reads messages, trailers and status of the gRPC-Web response.
+func readGRPCWebResponse(res *http.Response) ([][]byte, metadata.MD, *status.Status, error) {
+	trailers := metadata.MD{}
+	if res.Header.Get("Grpc-Status") != "" {
+		// Trailers-Only response
+		for k, v := range grpcHTTPHeaderToMD(res.Header) {
+			trailers[k] = v
+		}
+		stat, err := grpcStatusFromMD(trailers)
+		return nil, trailers, stat, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, trailers, status.New(httpStatusToGRPCCode(res.StatusCode), res.Status), nil
+	}
+	var msgs [][]byte
+	br := bufio.NewReader(res.Body)
+	for {
+		flags, b, err := readGRPCHTTPEnvelope(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, nil, err
+		}
+		if flags&grpcHTTPFlagTrailer == 0 {
+			msgs = append(msgs, b)
+			continue
+		}
+		tr := textproto.NewReader(bufio.NewReader(io.MultiReader(bytes.NewReader(b), strings.NewReader("\r\n"))))
+		h, err := tr.ReadMIMEHeader()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, nil, fmt.Errorf("invalid trailers: %w", err)
+		}
+		for k, v := range grpcHTTPHeaderToMD(http.Header(h)) {
+			trailers[k] = v
+		}
+		break
+	}
+	if trailers.Get("grpc-status") == nil {
+		return nil, nil, nil, errors.New("invalid gRPC-Web response: grpc-status is missing")
+	}
+	stat, err := grpcStatusFromMD(trailers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return msgs, trailers, stat, nil
+}
+
+// readConnectUnaryResponse reads the message, trailers and status of the Connect unary response.
+func readConnectUnaryResponse(res *http.Response) ([][]byte, metadata.MD, *status.Status, error) {
+	trailers := metadata.MD{}
+	for k, v := range grpcHTTPHeaderToMD(res.Header) {
+		if t, ok := strings.CutPrefix(k, "trailer-"); ok {
+			trailers[t] = v
+		}
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		stat, err := connectErrorToStatus(b, res.StatusCode)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, trailers, stat, nil
+	}
+	return [][]byte{b}, trailers, status.New(codes.OK, ""), nil
+}
+
+// readConnectStreamResponse reads messages, trailers and status of the Connect streaming response.
+func readConnectStreamResponse(res *http.Response) ([][]byte, metadata.MD, *status.Status, error) {
+	trailers := metadata.MD{}
+	if res.StatusCode != http.StatusOK {
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		stat, err := connectErrorToStatus(b, res.StatusCode)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, trailers, stat, nil
+	}
+	var msgs [][]byte
+	br := bufio.NewReader(res.Body)
+	for {
+		flags, b, err := readGRPCHTTPEnvelope(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil, nil, errors.New("invalid Connect response: end-stream message is missing")
+			}
+			return nil, nil, nil, err
+		}
+		if flags&grpcHTTPFlagEndStream == 0 {
+			msgs = append(msgs, b)
+			continue
+		}
+		es := &connectEndStream{}
+		if err := json.Unmarshal(b, es); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid end-stream message: %w", err)
+		}
+		for k, v := range es.Metadata {
+			trailers[strings.ToLower(k)] = v
+		}
+		if es.Error == nil {
+			return msgs, trailers, status.New(codes.OK, ""), nil
+		}
+		stat, err := es.Error.toStatus()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return msgs, trailers, stat, nil
+	}
+}
+
+// connectErrorToStatus converts the body of the Connect error response to status.
+func connectErrorToStatus(b []byte, statusCode int) (*status.Status, error) {
+	ce := &connectError{}
+	if err := json.Unmarshal(b, ce); err != nil || ce.Code == "" {
+		return status.New(httpStatusToGRPCCode(statusCode), http.StatusText(statusCode)), nil
+	}
+	return ce.toStatus()
+}
+
+func (e *connectError) toStatus() (*status.Status, error) {
+	c, ok := connectCodes[e.Code]
+	if !ok {
+		c = codes.Unknown
+	}
+	s := &spb.Status{
+		Code:    int32(c),
+		Message: e.Message,
+	}
+	for _, d := range e.Details {
+		v, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(d.Value, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid error detail: %w", err)
+		}
+		s.Details = append(s.Details, &anypb.Any{TypeUrl: "type.googleapis.com/" + d.Type, Value: v})
+	}
+	return status.FromProto(s), nil
+}
+
+// grpcStatusFromMD returns the status from `grpc-status`, `grpc-message` and `grpc-status-details-bin` of the metadata.
+// These keys are removed from the metadata.
+func grpcStatusFromMD(md metadata.MD) (*status.Status, error) {
+	defer func() {
+		delete(md, "grpc-status")
+		delete(md, "grpc-message")
+		delete(md, "grpc-status-details-bin")
+	}()
+	if v := md.Get("grpc-status-details-bin"); len(v) > 0 {
+		b, err := decodeBinHeader(v[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid grpc-status-details-bin: %w", err)
+		}
+		s := &spb.Status{}
+		if err := proto.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("invalid grpc-status-details-bin: %w", err)
+		}
+		return status.FromProto(s), nil
+	}
+	var c codes.Code
+	if v := md.Get("grpc-status"); len(v) > 0 {
+		n, err := strconv.ParseUint(v[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grpc-status: %s", v[0])
+		}
+		c = codes.Code(n)
+	}
+	var msg string
+	if v := md.Get("grpc-message"); len(v) > 0 {
+		m, err := url.PathUnescape(v[0])
+		if err != nil {
+			m = v[0]
+		}
+		msg = m
+	}
+	return status.New(c, msg), nil
+}
+
+func decodeBinHeader(v string) ([]byte, error) {
+	if len(v)%4 == 0 {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
+
+// grpcHTTPHeaderToMD converts HTTP headers to metadata with lowercase keys.
+func grpcHTTPHeaderToMD(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range h {
+		md[strings.ToLower(k)] = append([]string{}, v...)
+	}
+	return md
+}
+
+// httpStatusToGRPCCode converts HTTP status to gRPC code.
+// ref: https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+func httpStatusToGRPCCode(s int) codes.Code {
+	switch s {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
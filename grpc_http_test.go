@@ -0,0 +1,571 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestGrpcRunnerOverHTTP(t *testing.T) {
+	tests := []struct {
+		name            string
+		req             *grpcRequest
+		wantStatus      any
+		wantResMessage  any
+		wantResCount    int
+		wantResTrailers metadata.MD
+	}{
+		{
+			"Unary RPC",
+			&grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "Hello",
+				headers: metadata.MD{"3rd": {"stone"}},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "alice", "num": 3}},
+				},
+			},
+			int(codes.OK),
+			map[string]any{"message": "hello alice (stone)", "num": float64(6), "create_time": nil},
+			1,
+			metadata.MD{"hello": {"trailer"}},
+		},
+		{
+			"Unary RPC error",
+			&grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "Hello",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "nobody"}},
+				},
+			},
+			int(codes.NotFound),
+			"user not found",
+			0,
+			metadata.MD{"hello": {"trailer"}},
+		},
+		{
+			"Server streaming RPC",
+			&grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "ListHello",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "alice", "num": 2}},
+				},
+			},
+			int64(codes.OK),
+			map[string]any{"message": "hello alice 2", "num": float64(2), "create_time": nil},
+			2,
+			metadata.MD{"hello": {"trailer"}},
+		},
+		{
+			"Client streaming RPC",
+			&grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "MultiHello",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "alice", "num": 1}},
+					{op: GRPCOpMessage, params: map[string]any{"name": "bob", "num": 2}},
+				},
+			},
+			int64(codes.OK),
+			map[string]any{"message": "hello alice, bob", "num": float64(3), "create_time": nil},
+			1,
+			metadata.MD{"hello": {"trailer"}},
+		},
+	}
+	ts := grpcHTTPTestServer(t)
+	ctx := context.Background()
+	for _, protocol := range []string{GRPCProtocolGRPCWeb, GRPCProtocolConnect} {
+		for _, codec := range []string{GRPCCodecProto, GRPCCodecJSON} {
+			for _, tt := range tests {
+				t.Run(fmt.Sprintf("%s %s %s", protocol, codec, tt.name), func(t *testing.T) {
+					o, err := New()
+					if err != nil {
+						t.Fatal(err)
+					}
+					r, err := newGrpcRunner("greq", ts.URL)
+					if err != nil {
+						t.Fatal(err)
+					}
+					r.operator = o
+					r.protocol = protocol
+					r.codec = codec
+					r.protos = []string{filepath.Join(testutil.Testdata(), "grpctest.proto")}
+					if err := r.Run(ctx, tt.req); err != nil {
+						t.Fatal(err)
+					}
+					res, ok := o.store.latest()["res"].(map[string]any)
+					if !ok {
+						t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+					}
+					if diff := cmp.Diff(res["status"], tt.wantStatus); diff != "" {
+						t.Error(diff)
+					}
+					if diff := cmp.Diff(res["message"], tt.wantResMessage); diff != "" {
+						t.Error(diff)
+					}
+					msgs, _ := res["messages"].([]map[string]any)
+					if len(msgs) != tt.wantResCount {
+						t.Errorf("got %v\nwant %v", len(msgs), tt.wantResCount)
+					}
+					h, ok := res["headers"].(metadata.MD)
+					if !ok {
+						t.Fatalf("invalid res headers: %v", res["headers"])
+					}
+					if diff := cmp.Diff(h.Get("hello"), []string{"header"}); diff != "" {
+						t.Error(diff)
+					}
+					if diff := cmp.Diff(res["trailers"], tt.wantResTrailers); diff != "" {
+						t.Error(diff)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestGrpcRunnerOverHTTPBidiStreaming(t *testing.T) {
+	ts := grpcHTTPTestServer(t)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.protocol = GRPCProtocolConnect
+	r.protos = []string{filepath.Join(testutil.Testdata(), "grpctest.proto")}
+	req := &grpcRequest{
+		service: "grpctest.GrpcTestService",
+		method:  "HelloChat",
+		headers: metadata.MD{},
+		messages: []*grpcMessage{
+			{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+		},
+	}
+	if err := r.Run(context.Background(), req); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestGrpcRunnerOverHTTPUsingReflection(t *testing.T) {
+	hs := grpcHTTPTestServer(t)
+	tests := []struct {
+		name              string
+		disableReflection bool
+		wantErr           bool
+	}{
+		{"server reflection over native gRPC", false, false},
+		{"server reflection is not available", true, true},
+	}
+	ctx := context.Background()
+	for _, protocol := range []string{GRPCProtocolGRPCWeb, GRPCProtocolConnect} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", protocol, tt.name), func(t *testing.T) {
+				gs := testutil.GRPCServer(t, false, tt.disableReflection)
+				o, err := New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := newGrpcRunner("greq", gs.Addr())
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() {
+					_ = r.Close()
+				})
+				useTLS := false
+				r.operator = o
+				r.tls = &useTLS
+				r.protocol = protocol
+				// The RPCs are sent to the gRPC-Web and Connect server
+				r.httpClient = &http.Client{Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, network, hs.Listener.Addr().String())
+					},
+				}}
+				req := &grpcRequest{
+					service: "grpctest.GrpcTestService",
+					method:  "Hello",
+					headers: metadata.MD{},
+					messages: []*grpcMessage{
+						{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+					},
+				}
+				if err := r.Run(ctx, req); err != nil {
+					if !tt.wantErr {
+						t.Error(err)
+						return
+					}
+					if !strings.Contains(err.Error(), "requires protos") {
+						t.Errorf("got %v\nwant error requiring protos", err)
+					}
+					return
+				}
+				if tt.wantErr {
+					t.Fatal("want error")
+				}
+				res, ok := o.store.latest()["res"].(map[string]any)
+				if !ok {
+					t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+				}
+				if diff := cmp.Diff(res["status"], int(codes.OK)); diff != "" {
+					t.Error(diff)
+				}
+			})
+		}
+	}
+}
+
+func TestGrpcRunnerOverHTTPProtosWithoutServices(t *testing.T) {
+	hs := grpcHTTPTestServer(t)
+	p := filepath.Join(t.TempDir(), "empty.proto")
+	if err := os.WriteFile(p, []byte("syntax = \"proto3\";\npackage empty;\nmessage Empty {}\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", hs.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.protocol = GRPCProtocolConnect
+	r.protos = []string{p}
+	req := &grpcRequest{
+		service: "grpctest.GrpcTestService",
+		method:  "Hello",
+		headers: metadata.MD{},
+		messages: []*grpcMessage{
+			{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+		},
+	}
+	err = r.Run(context.Background(), req)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if want := "cannot find method: grpctest.GrpcTestService/Hello"; err.Error() != want {
+		t.Errorf("got %v\nwant %v", err, want)
+	}
+}
+
+func TestGrpcRunnerOverHTTPResolveWithProxy(t *testing.T) {
+	hs := grpcHTTPTestServer(t)
+	ps := testutil.HTTPProxyServer(t, "", "")
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", "http://api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.protocol = GRPCProtocolConnect
+	r.protos = []string{filepath.Join(testutil.Testdata(), "grpctest.proto")}
+	addr := strings.TrimPrefix(hs.URL, "http://")
+	p, err := newRunnerProxy(ps.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.proxy = p
+	hr, err := newHostResolver(map[string]string{"api.example.com:80": addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.resolver = hr
+	req := &grpcRequest{
+		service: "grpctest.GrpcTestService",
+		method:  "Hello",
+		headers: metadata.MD{},
+		messages: []*grpcMessage{
+			{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+		},
+	}
+	if err := r.Run(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(ps.Requests(), []string{addr}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestGrpcRunnerOverHTTPPerRPCCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		insecure bool
+		want     string
+		wantErr  bool
+	}{
+		{"credentials", true, "Bearer xxxxx", false},
+		{"credentials require TLS", false, "", true},
+	}
+	ts := grpcHTTPTestServer(t)
+	ctx := context.Background()
+	for _, protocol := range []string{GRPCProtocolGRPCWeb, GRPCProtocolConnect} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s", protocol, tt.name), func(t *testing.T) {
+				creds, err := newGrpcPerRPCCredentials(&grpcCredentialsConfig{
+					Bearer:   &grpcBearerConfig{Token: "xxxxx"},
+					Insecure: tt.insecure,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				o, err := New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := newGrpcRunner("greq", ts.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rec := &headerRecorder{rt: http.DefaultTransport}
+				r.operator = o
+				r.protocol = protocol
+				r.perRPCCreds = creds
+				r.httpClient = &http.Client{Transport: rec}
+				r.protos = []string{filepath.Join(testutil.Testdata(), "grpctest.proto")}
+				req := &grpcRequest{
+					service: "grpctest.GrpcTestService",
+					method:  "Hello",
+					headers: metadata.MD{},
+					messages: []*grpcMessage{
+						{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+					},
+				}
+				if err := r.Run(ctx, req); err != nil {
+					if !tt.wantErr {
+						t.Error(err)
+					}
+					return
+				}
+				if tt.wantErr {
+					t.Fatal("want error")
+				}
+				if got := rec.headers[len(rec.headers)-1].Get("Authorization"); got != tt.want {
+					t.Errorf("got %v\nwant %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateGRPCOverHTTPOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      *grpcRunnerConfig
+		wantErr bool
+	}{
+		{"native gRPC", &grpcRunnerConfig{Compression: "gzip", MaxRecvMsgSize: 10, Authority: "example.com"}, false},
+		{"connect without dial options", &grpcRunnerConfig{Protocol: GRPCProtocolConnect, ServerName: "example.com"}, false},
+		{"connect with compression", &grpcRunnerConfig{Protocol: GRPCProtocolConnect, Compression: "gzip"}, true},
+		{"grpc-web with max message size", &grpcRunnerConfig{Protocol: GRPCProtocolGRPCWeb, MaxSendMsgSize: 10}, true},
+		{"grpc-web with keepalive", &grpcRunnerConfig{Protocol: GRPCProtocolGRPCWeb, Keepalive: &grpcKeepaliveConfig{Time: "10sec"}}, true},
+		{"connect with retry policy", &grpcRunnerConfig{Protocol: GRPCProtocolConnect, RetryPolicy: &grpcRetryPolicyConfig{MaxAttempts: 2}}, true},
+		{"connect with authority", &grpcRunnerConfig{Protocol: GRPCProtocolConnect, Authority: "example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateGRPCOverHTTPOptions(tt.in); (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// headerRecorder records the headers of the requests.
+type headerRecorder struct {
+	rt      http.RoundTripper
+	headers []http.Header
+}
+
+func (r *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.headers = append(r.headers, req.Header.Clone())
+	return r.rt.RoundTrip(req)
+}
+
+func TestValidateGRPCProtocol(t *testing.T) {
+	tests := []struct {
+		protocol string
+		codec    string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{GRPCProtocolGRPC, "", false},
+		{GRPCProtocolGRPCWeb, GRPCCodecJSON, false},
+		{GRPCProtocolConnect, GRPCCodecProto, false},
+		{"http3", "", true},
+		{GRPCProtocolConnect, "xml", true},
+		{GRPCProtocolGRPC, GRPCCodecJSON, true},
+	}
+	for _, tt := range tests {
+		if err := validateGRPCProtocol(tt.protocol, tt.codec); (err != nil) != tt.wantErr {
+			t.Errorf("%s %s: got %v\nwantErr %v", tt.protocol, tt.codec, err, tt.wantErr)
+		}
+	}
+}
+
+// grpcHTTPTestServer returns the server of grpctest.GrpcTestService using gRPC-Web and Connect protocol.
+func grpcHTTPTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	comp := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{testutil.Testdata()},
+		}),
+	}
+	fds, err := comp.Compile(context.Background(), "grpctest.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := fds[0].Services().ByName("GrpcTestService")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct := r.Header.Get("Content-Type")
+		web := strings.HasPrefix(ct, "application/grpc-web")
+		connectStream := strings.HasPrefix(ct, "application/connect")
+		codec := ct[strings.LastIndexAny(ct, "/+")+1:]
+		md := sd.Methods().ByName(protoreflect.Name(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]))
+		if md == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		unmarshal := func(b []byte) *dynamicpb.Message {
+			m := dynamicpb.NewMessage(md.Input())
+			if codec == GRPCCodecJSON {
+				err = protojson.Unmarshal(b, m)
+			} else {
+				err = proto.Unmarshal(b, m)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			return m
+		}
+		marshal := func(message string, num int64) []byte {
+			m := dynamicpb.NewMessage(md.Output())
+			m.Set(md.Output().Fields().ByName("message"), protoreflect.ValueOfString(message))
+			m.Set(md.Output().Fields().ByName("num"), protoreflect.ValueOfInt32(int32(num)))
+			var b []byte
+			if codec == GRPCCodecJSON {
+				b, err = protojson.Marshal(m)
+			} else {
+				b, err = proto.Marshal(m)
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			return b
+		}
+
+		var reqs []*dynamicpb.Message
+		if web || connectStream {
+			br := bufio.NewReader(r.Body)
+			for {
+				_, b, err := readGRPCHTTPEnvelope(br)
+				if err != nil {
+					break
+				}
+				reqs = append(reqs, unmarshal(b))
+			}
+		} else {
+			b, _ := io.ReadAll(r.Body)
+			reqs = append(reqs, unmarshal(b))
+		}
+		name := func(m *dynamicpb.Message) string {
+			return m.Get(md.Input().Fields().ByName("name")).String()
+		}
+		num := func(m *dynamicpb.Message) int64 {
+			return m.Get(md.Input().Fields().ByName("num")).Int()
+		}
+
+		var (
+			res    [][]byte
+			errMsg string
+		)
+		switch md.Name() {
+		case "Hello":
+			if name(reqs[0]) == "nobody" {
+				errMsg = "user not found"
+				break
+			}
+			res = append(res, marshal(fmt.Sprintf("hello %s (%s)", name(reqs[0]), r.Header.Get("3rd")), num(reqs[0])*2))
+		case "ListHello":
+			for i := int64(1); i <= num(reqs[0]); i++ {
+				res = append(res, marshal(fmt.Sprintf("hello %s %d", name(reqs[0]), i), i))
+			}
+		case "MultiHello":
+			var (
+				names []string
+				sum   int64
+			)
+			for _, m := range reqs {
+				names = append(names, name(m))
+				sum += num(m)
+			}
+			res = append(res, marshal(fmt.Sprintf("hello %s", strings.Join(names, ", ")), sum))
+		}
+
+		w.Header().Set("Content-Type", ct)
+		w.Header().Set("Hello", "header")
+		body := new(bytes.Buffer)
+		switch {
+		case web:
+			for _, b := range res {
+				writeGRPCHTTPEnvelope(body, 0, b)
+			}
+			tr := "grpc-status: 0\r\nhello: trailer\r\n"
+			if errMsg != "" {
+				tr = fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\nhello: trailer\r\n", codes.NotFound, strings.ReplaceAll(errMsg, " ", "%20"))
+			}
+			writeGRPCHTTPEnvelope(body, grpcHTTPFlagTrailer, []byte(tr))
+		case connectStream:
+			for _, b := range res {
+				writeGRPCHTTPEnvelope(body, 0, b)
+			}
+			es := `{"metadata":{"hello":["trailer"]}}`
+			if errMsg != "" {
+				es = fmt.Sprintf(`{"error":{"code":"not_found","message":%q},"metadata":{"hello":["trailer"]}}`, errMsg)
+			}
+			writeGRPCHTTPEnvelope(body, grpcHTTPFlagEndStream, []byte(es))
+		default:
+			w.Header().Set("Trailer-Hello", "trailer")
+			if errMsg != "" {
+				w.Header().Set("Content-Type", MediaTypeApplicationJSON)
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = fmt.Fprintf(w, `{"code":"not_found","message":%q}`, errMsg)
+				return
+			}
+			body.Write(res[0])
+		}
+		_, _ = w.Write(body.Bytes())
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
@@ -0,0 +1,178 @@
+package runn
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func (rnr *grpcRunner) resolveAllMethodsUsingProtosets() error {
+	var paths []string
+	if len(rnr.protosets) > 0 {
+		p, err := fetchPaths(strings.Join(rnr.protosets, string(os.PathListSeparator)))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p...)
+	}
+	if rnr.bufImage != "" {
+		p, err := fetchPaths(rnr.bufImage)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p...)
+	}
+	for _, p := range paths {
+		fds, err := loadFileDescriptorSet(p)
+		if err != nil {
+			return fmt.Errorf("failed to load descriptors from %s: %w", p, err)
+		}
+		files, err := newFileDescriptors(fds)
+		if err != nil {
+			return fmt.Errorf("failed to load descriptors from %s: %w", p, err)
+		}
+		if err := registerFiles(files); err != nil {
+			return err
+		}
+		for _, fd := range files {
+			for i := 0; i < fd.Services().Len(); i++ {
+				svc := fd.Services().Get(i)
+				for j := 0; j < svc.Methods().Len(); j++ {
+					m := svc.Methods().Get(j)
+					key := fmt.Sprintf("%s/%s", svc.FullName(), m.Name())
+					rnr.mds[key] = m
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// loadFileDescriptorSet loads the protoset ( FileDescriptorSet ) or the buf image.
+// The buf image is wire compatible with FileDescriptorSet, so it is loaded as FileDescriptorSet discarding buf specific fields.
+// The format is detected by the extension ( .json, .txtpb or binary ) and the file compressed with gzip ( .gz ) or zstd ( .zst ) is decompressed.
+func loadFileDescriptorSet(p string) (*descriptorpb.FileDescriptorSet, error) {
+	b, err := readFile(p)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(p))
+	switch ext {
+	case ".gz":
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(p, filepath.Ext(p))))
+	case ".zst":
+		r, err := zstd.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(p, filepath.Ext(p))))
+	}
+	fds := &descriptorpb.FileDescriptorSet{}
+	switch ext {
+	case ".json":
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, fds)
+	case ".txtpb", ".textproto":
+		err = prototext.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, fds)
+	default:
+		err = proto.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, fds)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fds, nil
+}
+
+// newFileDescriptors creates file descriptors from FileDescriptorSet.
+// The dependencies that are not included in the set are resolved using protoregistry.GlobalFiles.
+func newFileDescriptors(fds *descriptorpb.FileDescriptorSet) ([]protoreflect.FileDescriptor, error) {
+	fdps := map[string]*descriptorpb.FileDescriptorProto{}
+	for _, fdp := range fds.GetFile() {
+		fdps[fdp.GetName()] = fdp
+	}
+	local := new(protoregistry.Files)
+	resolver := &fallbackFileResolver{local: local}
+	var (
+		files   []protoreflect.FileDescriptor
+		resolve func(name string, seen map[string]struct{}) error
+	)
+	resolve = func(name string, seen map[string]struct{}) error {
+		if _, err := local.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fdp, ok := fdps[name]
+		if !ok {
+			// Use the registered descriptor
+			return nil
+		}
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("import cycle: %s", name)
+		}
+		seen[name] = struct{}{}
+		for _, dep := range fdp.GetDependency() {
+			if err := resolve(dep, seen); err != nil {
+				return err
+			}
+		}
+		fd, err := protodesc.NewFile(fdp, resolver)
+		if err != nil {
+			return err
+		}
+		if err := local.RegisterFile(fd); err != nil {
+			return err
+		}
+		files = append(files, fd)
+		return nil
+	}
+	for _, fdp := range fds.GetFile() {
+		if err := resolve(fdp.GetName(), map[string]struct{}{}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// fallbackFileResolver resolves descriptors using the local registry first and then protoregistry.GlobalFiles.
+type fallbackFileResolver struct {
+	local *protoregistry.Files
+}
+
+func (r *fallbackFileResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	fd, err := r.local.FindFileByPath(path)
+	if err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *fallbackFileResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	d, err := r.local.FindDescriptorByName(name)
+	if err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
@@ -0,0 +1,178 @@
+package runn
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGrpcRunnerWithProtosets(t *testing.T) {
+	fds := grpcTestFileDescriptorSet(t)
+	dir := t.TempDir()
+	protoset := filepath.Join(dir, "grpctest.protoset")
+	b, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(protoset, b, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// buf image has the buf specific field ( buf_extension ) in each file
+	image := proto.Clone(fds).(*descriptorpb.FileDescriptorSet)
+	for _, fdp := range image.GetFile() {
+		var ext []byte
+		ext = protowire.AppendTag(ext, 8042, protowire.BytesType)
+		ext = protowire.AppendBytes(ext, []byte{0x08, 0x01})
+		fdp.ProtoReflect().SetUnknown(ext)
+	}
+	bufImageJSON := filepath.Join(dir, "image.json")
+	b, err = protojson.Marshal(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bufImageJSON, b, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	bufImageGz := filepath.Join(dir, "image.binpb.gz")
+	b, err = proto.Marshal(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	if _, err := gw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bufImageGz, buf.Bytes(), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		protosets []string
+		bufImage  string
+	}{
+		{"protoset", []string{protoset}, ""},
+		{"buf image (json)", nil, bufImageJSON},
+		{"buf image (binary, gzip)", nil, bufImageGz},
+	}
+	ctx := context.Background()
+	useTLS := false
+	ts := testutil.GRPCServer(t, useTLS, true)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newGrpcRunner("greq", ts.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			r.tls = &useTLS
+			r.protosets = tt.protosets
+			r.bufImage = tt.bufImage
+			req := &grpcRequest{
+				service: "grpctest.GrpcTestService",
+				method:  "Hello",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+				},
+			}
+			if err := r.Run(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			msg, ok := res["message"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid message: %#v", res["message"])
+			}
+			if got, want := msg["message"], "hello"; got != want {
+				t.Errorf("got %v\nwant %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewFileDescriptors(t *testing.T) {
+	fds := grpcTestFileDescriptorSet(t)
+	t.Run("dependencies are resolved regardless of the order", func(t *testing.T) {
+		reversed := &descriptorpb.FileDescriptorSet{}
+		for i := len(fds.GetFile()) - 1; i >= 0; i-- {
+			reversed.File = append(reversed.File, fds.GetFile()[i])
+		}
+		files, err := newFileDescriptors(reversed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(files), len(fds.GetFile()); got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+	})
+	t.Run("missing dependencies are resolved using the registered descriptors", func(t *testing.T) {
+		var without []*descriptorpb.FileDescriptorProto
+		for _, fdp := range fds.GetFile() {
+			if fdp.GetName() == "grpctest.proto" {
+				without = append(without, fdp)
+			}
+		}
+		files, err := newFileDescriptors(&descriptorpb.FileDescriptorSet{File: without})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(files), 1; got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+	})
+	t.Run("missing dependencies", func(t *testing.T) {
+		fdp := &descriptorpb.FileDescriptorProto{
+			Name:       proto.String("missing.proto"),
+			Dependency: []string{"not/exist.proto"},
+		}
+		if _, err := newFileDescriptors(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}}); err == nil {
+			t.Error("want error")
+		}
+	})
+}
+
+// grpcTestFileDescriptorSet returns FileDescriptorSet of testdata/grpctest.proto including imports.
+func grpcTestFileDescriptorSet(t *testing.T) *descriptorpb.FileDescriptorSet {
+	t.Helper()
+	comp := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{testutil.Testdata()},
+		}),
+	}
+	files, err := comp.Compile(context.Background(), "grpctest.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+			protodesc.ToFileDescriptorProto(files[0]),
+		},
+	}
+}
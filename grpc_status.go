@@ -0,0 +1,76 @@
+package runn
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/goccy/go-json"
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // register google.rpc error details
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcStatusDetails decodes the details of the status to maps.
+// The details that cannot be resolved are recorded with `@type` and base64 encoded `value`.
+func grpcStatusDetails(stat *status.Status) []map[string]any {
+	details := []map[string]any{}
+	for _, a := range stat.Proto().GetDetails() {
+		b, err := protojson.MarshalOptions{
+			Resolver:        grpcTypeResolver{},
+			UseProtoNames:   true,
+			UseEnumNumbers:  true,
+			EmitUnpopulated: true,
+		}.Marshal(a)
+		var detail map[string]any
+		if err == nil {
+			err = json.Unmarshal(b, &detail)
+		}
+		if err != nil {
+			detail = map[string]any{
+				"@type": a.GetTypeUrl(),
+				"value": base64.StdEncoding.EncodeToString(a.GetValue()),
+			}
+		}
+		details = append(details, detail)
+	}
+	return details
+}
+
+// grpcTypeResolver resolves the message types using protoregistry.GlobalTypes and the descriptors registered in protoregistry.GlobalFiles ( protos or reflection ).
+type grpcTypeResolver struct{}
+
+func (grpcTypeResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if err == nil || !errors.Is(err, protoregistry.NotFound) {
+		return mt, err
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, protoregistry.NotFound
+	}
+	return dynamicpb.NewMessageType(md), nil
+}
+
+func (r grpcTypeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	name := url
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		name = url[i+1:]
+	}
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func (grpcTypeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (grpcTypeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
@@ -0,0 +1,113 @@
+package runn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestGrpcRunnerStatusDetails(t *testing.T) {
+	ctx := context.Background()
+	useTLS := false
+	ts := testutil.GRPCServer(t, useTLS, false)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", ts.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.tls = &useTLS
+	req := &grpcRequest{
+		service: "grpctest.GrpcTestService",
+		method:  "Hello",
+		headers: metadata.MD{"error-details": {"enable"}},
+		messages: []*grpcMessage{
+			{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+		},
+	}
+	if err := r.Run(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+	}
+	if diff := cmp.Diff(res["status"], int(codes.InvalidArgument)); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(res["message"], "invalid request"); diff != "" {
+		t.Error(diff)
+	}
+	want := []map[string]any{
+		{
+			"@type": "type.googleapis.com/google.rpc.BadRequest",
+			"field_violations": []any{
+				map[string]any{"field": "name", "description": "name is required"},
+			},
+		},
+		{
+			"@type":    "type.googleapis.com/google.rpc.ErrorInfo",
+			"reason":   "INVALID_NAME",
+			"domain":   "example.com",
+			"metadata": map[string]any{},
+		},
+	}
+	if diff := cmp.Diff(res["details"], want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestGrpcStatusDetails(t *testing.T) {
+	comp := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{testutil.Testdata()},
+		}),
+	}
+	fds, err := comp.Compile(context.Background(), "grpctest.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registerFiles(fds); err != nil {
+		t.Fatal(err)
+	}
+	md := fds[0].Messages().ByName("HelloResponse")
+	m := dynamicpb.NewMessage(md)
+	m.Set(md.Fields().ByName("message"), protoreflect.ValueOfString("hello"))
+	custom, err := anypb.New(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := &anypb.Any{TypeUrl: "type.googleapis.com/unknown.Detail", Value: []byte{0x08, 0x01}}
+	stat := status.New(codes.Internal, "internal")
+	p := stat.Proto()
+	p.Details = append(p.Details, custom, unknown)
+
+	got := grpcStatusDetails(status.FromProto(p))
+	want := []map[string]any{
+		{
+			"@type":       "type.googleapis.com/grpctest.HelloResponse",
+			"message":     "hello",
+			"num":         float64(0),
+			"create_time": nil,
+		},
+		{
+			"@type": "type.googleapis.com/unknown.Detail",
+			"value": "CAE=",
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -3,7 +3,10 @@ package runn
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -454,3 +457,114 @@ func TestGrpcRunnerWithTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestGrpcRunnerProxy(t *testing.T) {
+	ctx := context.Background()
+	useTLS := false
+	ts := testutil.GRPCServer(t, useTLS, false)
+	ps := testutil.HTTPProxyServer(t, "alice", "pass")
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", ts.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.tls = &useTLS
+	p, err := newRunnerProxy(strings.Replace(ps.URL, "http://", "http://alice:pass@", 1), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.proxy = p
+	req := &grpcRequest{
+		service: "grpctest.GrpcTestService",
+		method:  "Hello",
+		headers: metadata.MD{},
+		messages: []*grpcMessage{
+			{
+				op: GRPCOpMessage,
+				params: map[string]any{
+					"name": "alice",
+				},
+			},
+		},
+	}
+	if err := r.Run(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	if diff := cmp.Diff(ps.Requests(), []string{ts.Addr()}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestGrpcRunnerUnixSocket(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.GRPCServer(t, false, false)
+	// Forward the Unix domain socket to the gRPC server
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				upstream, err := net.Dial("tcp", ts.Addr())
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				go func() {
+					_, _ = io.Copy(upstream, conn)
+				}()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", fmt.Sprintf("unix://%s", sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	req := &grpcRequest{
+		service: "grpctest.GrpcTestService",
+		method:  "Hello",
+		headers: metadata.MD{},
+		messages: []*grpcMessage{
+			{
+				op: GRPCOpMessage,
+				params: map[string]any{
+					"name": "alice",
+				},
+			},
+		},
+	}
+	if err := r.Run(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	got := o.store.latest()["res"].(map[string]any)["message"].(map[string]any)["message"]
+	if want := "hello"; got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
@@ -5,23 +5,31 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
 	"net/textproto"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ajg/form"
 	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+	"github.com/k1LoW/duration"
+	"github.com/lestrrat-go/backoff/v2"
 )
 
 const (
@@ -30,17 +38,42 @@ const (
 	MediaTypeApplicationFormUrlencoded = "application/x-www-form-urlencoded"
 	MediaTypeMultipartFormData         = "multipart/form-data"
 	MediaTypeApplicationOctetStream    = "application/octet-stream"
+	MediaTypeApplicationXML            = "application/xml"
+	MediaTypeTextXML                   = "text/xml"
+	MediaTypeApplicationYAML           = "application/yaml"
+	MediaTypeApplicationNDJSON         = "application/x-ndjson"
+	MediaTypeApplicationMergePatchJSON = "application/merge-patch+json"
+	MediaTypeTextCSV                   = "text/csv"
+	MediaTypeTextEventStream           = "text/event-stream"
 )
 
 const (
-	httpStoreStatusKey   = "status"
-	httpStoreBodyKey     = "body"
-	httpStoreRawBodyKey  = "rawBody"
-	httpStoreHeaderKey   = "headers"
-	httpStoreCookieKey   = "cookies"
-	httpStoreResponseKey = "res"
+	httpStoreStatusKey    = "status"
+	httpStoreBodyKey      = "body"
+	httpStoreRawBodyKey   = "rawBody"
+	httpStoreHeaderKey    = "headers"
+	httpStoreCookieKey    = "cookies"
+	httpStoreTimingsKey   = "timings"
+	httpStoreEventsKey    = "events"
+	httpStoreRedirectsKey = "redirects"
+	httpStoreResponseKey  = "res"
+
+	// httpStoreContentEncodingKey is the key of the original Content-Encoding of the decompressed response
+	httpStoreContentEncodingKey = "contentEncoding"
 )
 
+const (
+	defaultHTTPRetryMinInterval = 100 * time.Millisecond
+	defaultHTTPRetryMaxInterval = 3 * time.Second
+)
+
+const defaultMaxRedirects = 10
+
+const httpUnixScheme = "http+unix"
+
+// defaultHandlerEndpoint is the endpoint of the runner using http.Handler.
+const defaultHandlerEndpoint = "http://localhost"
+
 var notFollowRedirectFn = func(req *http.Request, via []*http.Request) error {
 	return http.ErrUseLastResponse
 }
@@ -49,24 +82,51 @@ type httpRunner struct {
 	name              string
 	endpoint          *url.URL
 	client            *http.Client
-	handler           http.Handler
 	operator          *operator
-	validator         httpValidator
+	validator         HTTPValidator
+	validatorNames    []string
 	multipartBoundary string
 	cacert            []byte
 	cert              []byte
 	key               []byte
 	skipVerify        bool
 	useCookie         *bool
+	idleConnTimeout   time.Duration
+	maxIdleConns      int
+	disableKeepAlives bool
+	retry             *httpRetry
+	proxy             *runnerProxy
+	resolver          *hostResolver
+	auth              *httpAuth
+	// unixSocket is the path of the Unix domain socket of `http+unix://` endpoint
+	unixSocket string
+	// failOnGraphQLErrors fails the step when the GraphQL response has errors
+	failOnGraphQLErrors bool
+}
+
+// httpRetry - Retry policy on transport errors.
+type httpRetry struct {
+	count       int
+	minInterval time.Duration
+	maxInterval time.Duration
 }
 
 type httpRequest struct {
 	path      string
 	method    string
 	headers   map[string]string
+	query     url.Values
 	mediaType string
 	body      any
 	useCookie *bool
+	timeout   time.Duration
+	sse       *httpSSE
+	graphql   *httpGraphQL
+	// compress is the content coding to compress the request body
+	compress string
+	// followRedirect overrides notFollowRedirect of the runner
+	followRedirect *bool
+	maxRedirects   *int
 
 	multipartWriter   *multipart.Writer
 	multipartBoundary string
@@ -75,7 +135,7 @@ type httpRequest struct {
 }
 
 func newHTTPRunner(name, endpoint string) (*httpRunner, error) {
-	u, err := url.Parse(endpoint)
+	u, sock, err := parseHTTPEndpoint(endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -86,21 +146,179 @@ func newHTTPRunner(name, endpoint string) (*httpRunner, error) {
 			Transport: http.DefaultTransport.(*http.Transport).Clone(),
 			Timeout:   time.Second * 30,
 		},
-		validator: newNopValidator(),
+		validator:  newNopValidator(),
+		unixSocket: sock,
 	}, nil
 }
 
+// parseHTTPEndpoint parses the endpoint of the HTTP runner.
+// For `http+unix:///path/to.sock`, it returns `http://localhost` and the path of the Unix domain socket.
+func parseHTTPEndpoint(endpoint string) (*url.URL, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Scheme != httpUnixScheme {
+		return u, "", nil
+	}
+	if u.Host != "" || u.Path == "" {
+		return nil, "", fmt.Errorf("invalid endpoint: %s: the path of the Unix domain socket should be specified as http+unix:///path/to.sock", endpoint)
+	}
+	return &url.URL{Scheme: "http", Host: "localhost"}, u.Path, nil
+}
+
+func isHTTPEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "https://") || strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, httpUnixScheme+"://")
+}
+
+// validateHandlerConnectionOptions returns an error if the options of connection are set to the runner with http.Handler.
+// The requests to http.Handler do not use network, so the options cannot be applied.
+func validateHandlerConnectionOptions(c *httpRunnerConfig) error {
+	var opts []string
+	if c.IdleConnTimeout != "" {
+		opts = append(opts, "idleConnTimeout")
+	}
+	if c.MaxIdleConns != 0 {
+		opts = append(opts, "maxIdleConns")
+	}
+	if c.DisableKeepAlives {
+		opts = append(opts, "disableKeepAlives")
+	}
+	if c.Retry != nil {
+		opts = append(opts, "retry")
+	}
+	if c.Proxy != "" {
+		opts = append(opts, "proxy")
+	}
+	if c.NoProxy != "" {
+		opts = append(opts, "noProxy")
+	}
+	if len(c.Resolve) > 0 {
+		opts = append(opts, "resolve")
+	}
+	if len(opts) > 0 {
+		return fmt.Errorf("%s in HttpRunnerConfig cannot be used with http.Handler", strings.Join(opts, ", "))
+	}
+	return nil
+}
+
+// setConnectionOptions sets options of connection and retry to the runner.
+func (rnr *httpRunner) setConnectionOptions(c *httpRunnerConfig) error {
+	if c.IdleConnTimeout != "" {
+		d, err := duration.Parse(c.IdleConnTimeout)
+		if err != nil {
+			return fmt.Errorf("idleConnTimeout in HttpRunnerConfig is invalid: %w", err)
+		}
+		rnr.idleConnTimeout = d
+	}
+	if c.MaxIdleConns < 0 {
+		return fmt.Errorf("maxIdleConns in HttpRunnerConfig is invalid: %d", c.MaxIdleConns)
+	}
+	rnr.maxIdleConns = c.MaxIdleConns
+	rnr.disableKeepAlives = c.DisableKeepAlives
+	if c.Retry != nil && c.Retry.Count > 0 {
+		rt := &httpRetry{
+			count:       c.Retry.Count,
+			minInterval: defaultHTTPRetryMinInterval,
+			maxInterval: defaultHTTPRetryMaxInterval,
+		}
+		if c.Retry.MinInterval != "" {
+			d, err := duration.Parse(c.Retry.MinInterval)
+			if err != nil {
+				return fmt.Errorf("retry.minInterval in HttpRunnerConfig is invalid: %w", err)
+			}
+			rt.minInterval = d
+		}
+		if c.Retry.MaxInterval != "" {
+			d, err := duration.Parse(c.Retry.MaxInterval)
+			if err != nil {
+				return fmt.Errorf("retry.maxInterval in HttpRunnerConfig is invalid: %w", err)
+			}
+			rt.maxInterval = d
+		}
+		if rt.maxInterval < rt.minInterval {
+			rt.maxInterval = rt.minInterval
+		}
+		rnr.retry = rt
+	}
+	p, err := newRunnerProxy(c.Proxy, c.NoProxy)
+	if err != nil {
+		return fmt.Errorf("proxy in HttpRunnerConfig is invalid: %w", err)
+	}
+	rnr.proxy = p
+	hr, err := newHostResolver(c.Resolve)
+	if err != nil {
+		return fmt.Errorf("resolve in HttpRunnerConfig is invalid: %w", err)
+	}
+	rnr.resolver = hr
+	return nil
+}
+
 func newHTTPRunnerWithHandler(name string, h http.Handler) (*httpRunner, error) {
+	u, err := url.Parse(defaultHandlerEndpoint)
+	if err != nil {
+		return nil, err
+	}
 	return &httpRunner{
-		name:      name,
-		handler:   h,
+		name:     name,
+		endpoint: u,
+		client: &http.Client{
+			Transport: &handlerTransport{handler: h},
+			Timeout:   time.Second * 30,
+		},
 		validator: newNopValidator(),
 	}, nil
 }
 
+// handlerTransport is the http.RoundTripper that serves requests with http.Handler in memory.
+// It allows the runner using http.Handler to build requests, store cookies, follow redirects and validate in the same way as the network client.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t *handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		// RoundTrip must always close the body, including on errors
+		defer req.Body.Close()
+	}
+	sreq := req.Clone(req.Context())
+	// Convert to the server request
+	u, err := url.ParseRequestURI(req.URL.RequestURI())
+	if err != nil {
+		return nil, err
+	}
+	sreq.URL = u
+	sreq.RequestURI = req.URL.RequestURI()
+	sreq.RemoteAddr = "192.0.2.1:1234"
+	if sreq.Host == "" {
+		sreq.Host = req.URL.Host
+	}
+	if sreq.Body == nil {
+		sreq.Body = http.NoBody
+	}
+	if req.URL.Scheme == "https" {
+		sreq.TLS = &tls.ConnectionState{
+			Version:           tls.VersionTLS12,
+			HandshakeComplete: true,
+			ServerName:        req.URL.Hostname(),
+		}
+	}
+	w := httptest.NewRecorder()
+	t.handler.ServeHTTP(w, sreq)
+	if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+	res := w.Result()
+	res.Request = req
+	return res, nil
+}
+
 func (r *httpRequest) validate() error {
 	switch r.method {
 	case http.MethodPost, http.MethodPatch:
+		if r.graphql != nil {
+			break
+		}
 		if r.mediaType == "" {
 			return fmt.Errorf("%s method requires mediaType", r.method)
 		}
@@ -111,14 +329,84 @@ func (r *httpRequest) validate() error {
 	if r.isMultipartFormDataMediaType() {
 		return nil
 	}
-	switch r.mediaType {
-	case MediaTypeApplicationJSON, MediaTypeTextPlain, MediaTypeApplicationFormUrlencoded, MediaTypeApplicationOctetStream, "":
-	default:
-		return fmt.Errorf("unsupported mediaType: %s", r.mediaType)
+	if r.mediaType != "" {
+		if _, _, err := mime.ParseMediaType(r.mediaType); err != nil {
+			return fmt.Errorf("invalid mediaType: %s: %w", r.mediaType, err)
+		}
 	}
 	return nil
 }
 
+// checkRedirectFn returns the CheckRedirect function of http.Client for the request.
+// The requests of the followed redirects are passed to onRedirect.
+func (r *httpRequest) checkRedirectFn(base func(*http.Request, []*http.Request) error, onRedirect func(*http.Request)) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if r.followRedirect != nil {
+			if !*r.followRedirect {
+				return http.ErrUseLastResponse
+			}
+		} else if base != nil {
+			if err := base(req, via); err != nil {
+				return err
+			}
+		}
+		if r.maxRedirects != nil {
+			if len(via) > *r.maxRedirects {
+				// Stop following and use the last redirect response
+				return http.ErrUseLastResponse
+			}
+		} else if len(via) >= defaultMaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", defaultMaxRedirects)
+		}
+		if req.Response != nil {
+			onRedirect(req)
+		}
+		return nil
+	}
+}
+
+// redirectToMap converts the redirect response to the map recorded in `res.redirects`.
+func redirectToMap(res *http.Response) map[string]any {
+	m := map[string]any{
+		"status":  res.StatusCode,
+		"headers": res.Header,
+	}
+	var host string
+	if res.Request != nil && res.Request.URL != nil {
+		m["url"] = res.Request.URL.String()
+		host = res.Request.URL.Host
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, c := range res.Cookies() {
+		// If the Domain attribute is not specified, the host is taken over
+		if c.Domain == "" {
+			c.Domain = host
+		}
+		cookies[c.Name] = c
+	}
+	m["cookies"] = cookies
+	return m
+}
+
+// pathWithQuery returns the path with the query parameters of `query:` appended.
+func (r *httpRequest) pathWithQuery() (string, error) {
+	if len(r.query) == 0 {
+		return r.path, nil
+	}
+	u, err := url.Parse(r.path)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, vs := range r.query {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func (r *httpRequest) encodeBody() (io.Reader, error) {
 	if r.body == nil {
 		return nil, nil
@@ -126,14 +414,15 @@ func (r *httpRequest) encodeBody() (io.Reader, error) {
 	if r.isMultipartFormDataMediaType() {
 		return r.encodeMultipart()
 	}
-	switch r.mediaType {
-	case MediaTypeApplicationJSON:
+	mt := baseMediaType(r.mediaType)
+	switch {
+	case mt == MediaTypeApplicationJSON || strings.HasSuffix(mt, "+json"):
 		b, err := json.Marshal(r.body)
 		if err != nil {
 			return nil, err
 		}
 		return bytes.NewBuffer(b), nil
-	case MediaTypeApplicationFormUrlencoded:
+	case mt == MediaTypeApplicationFormUrlencoded:
 		values, ok := r.body.(map[string]any)
 		if !ok {
 			return nil, fmt.Errorf("invalid body: %v", r.body)
@@ -143,41 +432,170 @@ func (r *httpRequest) encodeBody() (io.Reader, error) {
 			return nil, err
 		}
 		return buf, nil
-	case MediaTypeApplicationOctetStream:
-		switch v := r.body.(type) {
-		case map[string]any:
-			vv, ok := v["filename"]
-			if !ok {
-				return nil, fmt.Errorf("invalid body: %v", r.body)
-			}
-			fileName, ok := vv.(string)
-			if !ok {
-				return nil, fmt.Errorf("invalid body: %v", r.body)
-			}
-			b, err := readFile(filepath.Join(r.root, fileName))
+	case mt == MediaTypeTextPlain:
+		s, ok := r.body.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid body: %v", r.body)
+		}
+		return strings.NewReader(s), nil
+	case mt == MediaTypeApplicationXML || mt == MediaTypeTextXML || strings.HasSuffix(mt, "+xml"):
+		m, ok := r.body.(map[string]any)
+		if !ok || r.isFilenameBody() {
+			return r.encodeRaw()
+		}
+		b, err := encodeXML(m)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case mt == MediaTypeApplicationYAML || strings.HasSuffix(mt, "+yaml"):
+		if _, ok := r.body.(string); ok || r.isFilenameBody() {
+			return r.encodeRaw()
+		}
+		b, err := yaml.Marshal(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case mt == MediaTypeApplicationNDJSON:
+		l, ok := r.body.([]any)
+		if !ok {
+			return r.encodeRaw()
+		}
+		buf := new(bytes.Buffer)
+		for _, v := range l {
+			b, err := json.Marshal(v)
 			if err != nil {
 				return nil, err
 			}
-			return bytes.NewBuffer(b), nil
-		case string:
-			s, ok := r.body.(string)
-			if !ok {
-				return nil, fmt.Errorf("invalid body: %v", r.body)
-			}
-			return strings.NewReader(s), nil
-		case []byte:
-			return bytes.NewBuffer(r.body.([]byte)), nil
+			buf.Write(b)
+			buf.WriteString("\n")
 		}
-		return nil, fmt.Errorf("invalid body: %v", r.body)
-	case MediaTypeTextPlain:
-		s, ok := r.body.(string)
+		return buf, nil
+	default:
+		// application/octet-stream and other media types
+		return r.encodeRaw()
+	}
+}
+
+// isFilenameBody returns whether the body is `filename:` only.
+func (r *httpRequest) isFilenameBody() bool {
+	m, ok := r.body.(map[string]any)
+	if !ok || len(m) != 1 {
+		return false
+	}
+	_, ok = m["filename"].(string)
+	return ok
+}
+
+// encodeRaw encodes the body as it is. The body is a string, binary or `filename:` of the file to be read.
+func (r *httpRequest) encodeRaw() (io.Reader, error) {
+	switch v := r.body.(type) {
+	case map[string]any:
+		vv, ok := v["filename"]
 		if !ok {
 			return nil, fmt.Errorf("invalid body: %v", r.body)
 		}
-		return strings.NewReader(s), nil
+		fileName, ok := vv.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid body: %v", r.body)
+		}
+		b, err := readFile(filepath.Join(r.root, fileName))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case string:
+		return strings.NewReader(v), nil
+	case []byte:
+		return bytes.NewBuffer(v), nil
+	}
+	return nil, fmt.Errorf("invalid body: %v", r.body)
+}
+
+// baseMediaType returns the media type without parameters.
+func baseMediaType(mediaType string) string {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		mt = strings.TrimSpace(strings.Split(mediaType, ";")[0])
+	}
+	return strings.ToLower(mt)
+}
+
+// encodeXML encodes map into XML.
+// It is the reverse of decodeXMLBody: keys with the "-" prefix are attributes, "#text" is text and list is repeated elements.
+func encodeXML(m map[string]any) ([]byte, error) {
+	if len(m) != 1 {
+		return nil, fmt.Errorf("XML body must have a single root element: %v", m)
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	for k, v := range m {
+		if err := encodeXMLElement(enc, k, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXMLElement(enc *xml.Encoder, name string, v any) error {
+	if l, ok := v.([]any); ok {
+		for _, vv := range l {
+			if err := encodeXMLElement(enc, name, vv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	se := xml.StartElement{Name: xml.Name{Local: name}}
+	switch vv := v.(type) {
+	case map[string]any:
+		var keys []string
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var children []string
+		for _, k := range keys {
+			if strings.HasPrefix(k, xmlAttrPrefix) {
+				se.Attr = append(se.Attr, xml.Attr{Name: xml.Name{Local: strings.TrimPrefix(k, xmlAttrPrefix)}, Value: fmt.Sprintf("%v", vv[k])})
+				continue
+			}
+			if k == xmlTextKey {
+				continue
+			}
+			children = append(children, k)
+		}
+		if err := enc.EncodeToken(se); err != nil {
+			return err
+		}
+		if t, ok := vv[xmlTextKey]; ok {
+			if err := enc.EncodeToken(xml.CharData(fmt.Sprintf("%v", t))); err != nil {
+				return err
+			}
+		}
+		for _, k := range children {
+			if err := encodeXMLElement(enc, k, vv[k]); err != nil {
+				return err
+			}
+		}
+	case nil:
+		if err := enc.EncodeToken(se); err != nil {
+			return err
+		}
 	default:
-		return nil, fmt.Errorf("unsupported mediaType: %s", r.mediaType)
+		if err := enc.EncodeToken(se); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprintf("%v", vv))); err != nil {
+			return err
+		}
 	}
+	return enc.EncodeToken(se.End())
 }
 
 func (r *httpRequest) isMultipartFormDataMediaType() bool {
@@ -273,59 +691,52 @@ func (r *httpRequest) setContentTypeHeader(req *http.Request) {
 	}
 }
 
-func (r *httpRequest) setCookieHeader(req *http.Request, cookies map[string]map[string]*http.Cookie) {
-	if r.useCookie != nil && *r.useCookie {
-		domain := req.URL.Hostname()
-		path := req.URL.Path
-		for host, domainCookies := range cookies {
-			// Ignore port number
-			splitHostPort := strings.Split(host, ":")
-			host = splitHostPort[0]
-
-			if host == "localhost" {
-				if l, err := isLocalhost(domain); !l || err != nil {
-					continue
-				}
-			} else if !strings.HasSuffix(domain, host) {
-				continue
-			}
-
-			for _, cookie := range domainCookies {
-				if cookie.Path == "" || strings.HasPrefix(path, cookie.Path) {
-					if cookie.Expires.IsZero() || cookie.Expires.After(time.Now()) {
-						req.AddCookie(cookie)
-					}
-				}
-			}
-		}
+// setCookieHeader sets cookies of the cookie jar that match the request URL if useCookie is true.
+func (r *httpRequest) setCookieHeader(req *http.Request, jar *cookieJar) {
+	if r.useCookie == nil || !*r.useCookie || jar == nil {
+		return
 	}
-}
-
-func isLocalhost(domain string) (bool, error) {
-	ips, err := net.LookupIP(domain)
-	if err != nil {
-		return false, err
+	for _, c := range jar.Cookies(req.URL) {
+		req.AddCookie(c)
 	}
-	for _, ip := range ips {
-		if ip.IsLoopback() {
-			return true, err
-		}
-	}
-
-	return false, nil
 }
 
 func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
+	return rnr.run(ctx, r, newHTTPTimer())
+}
+
+// run sends the HTTP request and records the timing breakdown to the timer.
+func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, timer *httpTimer) error {
 	r.multipartBoundary = rnr.multipartBoundary
 	r.root = rnr.operator.root
+	if err := r.setGraphQL(); err != nil {
+		return err
+	}
 	reqBody, err := r.encodeBody()
 	if err != nil {
 		return err
 	}
+	if reqBody != nil && r.compress != "" {
+		reqBody, err = compressBody(r.compress, reqBody)
+		if err != nil {
+			return err
+		}
+	}
+	var authBody []byte
+	if rnr.auth != nil && reqBody != nil {
+		// Read the body in advance to sign it or to send it again in response to the authentication challenge.
+		authBody, err = io.ReadAll(reqBody)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(authBody)
+	}
 
 	var (
-		req *http.Request
-		res *http.Response
+		req             *http.Request
+		res             *http.Response
+		redirects       []any
+		contentEncoding string
 	)
 	switch {
 	case rnr.client != nil:
@@ -340,6 +751,34 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 				ts.TLSClientConfig = new(tls.Config)
 			}
 			ts.TLSClientConfig.InsecureSkipVerify = rnr.skipVerify
+			if rnr.idleConnTimeout > 0 {
+				ts.IdleConnTimeout = rnr.idleConnTimeout
+			}
+			if rnr.maxIdleConns > 0 {
+				ts.MaxIdleConns = rnr.maxIdleConns
+			}
+			ts.DisableKeepAlives = rnr.disableKeepAlives
+			if rnr.proxy != nil {
+				ts.Proxy = rnr.proxy.httpProxyFunc()
+			}
+			switch {
+			case rnr.proxy != nil && rnr.resolver != nil:
+				// Dial via the proxy with CONNECT to the overridden address as with gRPC runner
+				ts.Proxy = nil
+				ts.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+					return rnr.proxy.dialContext(ctx, rnr.resolver.resolve(addr))
+				}
+			case rnr.resolver != nil:
+				ts.DialContext = rnr.resolver.dialContext
+			}
+			if rnr.unixSocket != "" {
+				sock := rnr.unixSocket
+				ts.Proxy = nil
+				ts.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", sock)
+				}
+			}
 		}
 		if len(rnr.cacert) != 0 {
 			certpool, err := x509.SystemCertPool()
@@ -369,7 +808,11 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 			ts.TLSClientConfig.Certificates = []tls.Certificate{cert}
 		}
 
-		u, err := mergeURL(rnr.endpoint, r.path)
+		p, err := r.pathWithQuery()
+		if err != nil {
+			return err
+		}
+		u, err := mergeURL(rnr.endpoint, p)
 		if err != nil {
 			return err
 		}
@@ -378,18 +821,24 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 			return err
 		}
 		r.setContentTypeHeader(req)
+		if reqBody != nil && r.compress != "" {
+			req.Header.Set("Content-Encoding", r.compress)
+		}
 
 		// Override useCookie
 		if r.useCookie == nil && rnr.useCookie != nil && *rnr.useCookie {
 			r.useCookie = rnr.useCookie
 		}
-		r.setCookieHeader(req, rnr.operator.store.cookies)
+		r.setCookieHeader(req, rnr.operator.store.cookieJar)
 		for k, v := range r.headers {
 			req.Header.Set(k, v)
 			if k == "Host" {
 				req.Host = v
 			}
 		}
+		if err := rnr.authenticate(ctx, req, authBody); err != nil {
+			return err
+		}
 
 		rnr.operator.capturers.captureHTTPRequest(rnr.name, req)
 
@@ -397,34 +846,79 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 			return err
 		}
 
-		res, err = rnr.client.Do(req)
+		c := *rnr.client
+		if r.timeout > 0 {
+			// Override timeout of the runner
+			c.Timeout = r.timeout
+		}
+		jar := rnr.operator.store.cookieJar
+		if c.Jar == nil && jar != nil {
+			c.Jar = &cookieRecorder{jar: jar}
+		}
+		c.CheckRedirect = r.checkRedirectFn(rnr.client.CheckRedirect, func(req *http.Request) {
+			redirects = append(redirects, redirectToMap(req.Response))
+			if c.Jar != nil && r.useCookie != nil && *r.useCookie {
+				// Send cookies of the cookie jar instead of the copied Cookie header
+				req.Header.Del("Cookie")
+				r.setCookieHeader(req, jar)
+				if v, ok := r.headers["Cookie"]; ok {
+					req.Header.Set("Cookie", v)
+				}
+			}
+		})
+		client := &c
+		timer.start()
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.clientTrace()))
+		res, err = rnr.do(ctx, client, req)
 		if err != nil {
 			return err
 		}
-		defer res.Body.Close()
-	case rnr.handler != nil:
-		req = httptest.NewRequest(r.method, r.path, reqBody)
-		if r.mediaType != "" {
-			req.Header.Set("Content-Type", r.mediaType)
-		}
-		for k, v := range r.headers {
-			req.Header.Set(k, v)
+		if rnr.auth.challenged(res) {
+			_ = res.Body.Close()
+			req = req.Clone(req.Context())
+			if req.GetBody != nil {
+				req.Body, err = req.GetBody()
+				if err != nil {
+					return err
+				}
+			}
+			req.Header.Del("Authorization")
+			if err := rnr.authenticate(ctx, req, authBody); err != nil {
+				return err
+			}
+			rnr.operator.capturers.captureHTTPRequest(rnr.name, req)
+			res, err = rnr.do(ctx, client, req)
+			if err != nil {
+				return err
+			}
 		}
-
-		rnr.operator.capturers.captureHTTPRequest(rnr.name, req)
-
-		if err := rnr.validator.ValidateRequest(ctx, req); err != nil {
+		contentEncoding, err = decompressResponse(res)
+		if err != nil {
+			_ = res.Body.Close()
 			return err
 		}
-		w := httptest.NewRecorder()
-		rnr.handler.ServeHTTP(w, req)
-		res = w.Result()
 		defer res.Body.Close()
 	default:
 		return fmt.Errorf("invalid http runner: %s", rnr.name)
 	}
 
+	if redirects == nil {
+		redirects = []any{}
+	}
+
+	var events []any
+	if isEventStream(res) {
+		// Read the event stream before capturing the response, because the stream may not end.
+		var raw []byte
+		raw, events, err = rnr.readEvents(res, r.sse)
+		if err != nil {
+			return err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(raw))
+	}
+
 	rnr.operator.capturers.captureHTTPResponse(rnr.name, res)
+	rnr.operator.openAPICoverage.record(rnr.validator, req, res)
 
 	if err := rnr.validator.ValidateResponse(ctx, req, res); err != nil {
 		var target *UnsupportedError
@@ -439,20 +933,33 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 	if err != nil {
 		return err
 	}
+	timer.done()
+	timings := timer.timings()
+	rnr.operator.capturers.captureHTTPTimings(rnr.name, timings)
 
 	d := map[string]any{}
 	d[httpStoreStatusKey] = res.StatusCode
-	if strings.Contains(res.Header.Get("Content-Type"), "json") && len(resBody) > 0 {
-		var b any
-		if err := json.Unmarshal(resBody, &b); err != nil {
+	b, err := decodeHTTPResponseBody(res.Header.Get("Content-Type"), resBody)
+	if err != nil {
+		if isStrictHTTPResponseBodyDecoding(res.Header.Get("Content-Type")) {
 			return err
 		}
-		d[httpStoreBodyKey] = b
-	} else {
-		d[httpStoreBodyKey] = nil
+		rnr.operator.Debugf("Skip decoding response body: %s\n", err.Error())
 	}
+	d[httpStoreBodyKey] = b
 	d[httpStoreRawBodyKey] = string(resBody)
 	d[httpStoreHeaderKey] = res.Header
+	d[httpStoreTimingsKey] = timings.toMap()
+	d[httpStoreRedirectsKey] = redirects
+	d[httpStoreContentEncodingKey] = contentEncoding
+	if events != nil {
+		d[httpStoreEventsKey] = events
+	}
+	var graphqlErrs []any
+	if r.graphql != nil {
+		d[httpStoreGraphQLDataKey], graphqlErrs = graphqlResult(b)
+		d[httpStoreGraphQLErrorsKey] = graphqlErrs
+	}
 
 	cookies := res.Cookies()
 
@@ -468,7 +975,6 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 		}
 
 		d[httpStoreCookieKey] = keyMap
-		rnr.operator.recordToCookie(cookies)
 	} else {
 		d[httpStoreCookieKey] = map[string]*http.Cookie{}
 	}
@@ -477,9 +983,93 @@ func (rnr *httpRunner) Run(ctx context.Context, r *httpRequest) error {
 		string(httpStoreResponseKey): d,
 	})
 
+	if rnr.failOnGraphQLErrors && len(graphqlErrs) > 0 {
+		return graphqlError(graphqlErrs)
+	}
+
 	return nil
 }
 
+// do sends the HTTP request. If the retry policy is set, it retries idempotent requests with exponential backoff on transport errors.
+func (rnr *httpRunner) do(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	if rnr.retry == nil || !isIdempotentRequest(req) {
+		return client.Do(req)
+	}
+	p := backoff.Exponential(
+		backoff.WithMaxRetries(0),
+		backoff.WithMinInterval(rnr.retry.minInterval),
+		backoff.WithMaxInterval(rnr.retry.maxInterval),
+		backoff.WithMultiplier(defaultMultiplier),
+		backoff.WithJitterFactor(defaultJitter),
+	)
+	ctrl := p.Start(ctx)
+	var (
+		res *http.Response
+		err error
+		i   int
+	)
+	for backoff.Continue(ctrl) {
+		if i > 0 {
+			rnr.operator.Debugf("Retry HTTP request (%d/%d): %v\n", i, rnr.retry.count, err)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+		}
+		started := time.Now()
+		res, err = client.Do(req)
+		if err == nil || !isRetryableTransportError(err) || i >= rnr.retry.count {
+			break
+		}
+		if ctx.Err() != nil || (client.Timeout > 0 && time.Since(started) >= client.Timeout) {
+			// The timeout of the step or the runner is exceeded
+			break
+		}
+		i++
+	}
+	if res == nil && err == nil {
+		return nil, ctx.Err()
+	}
+	return res, err
+}
+
+// isRetryableTransportError returns whether the error is a transient transport error such as connection reset or timeout.
+func isRetryableTransportError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+	return false
+}
+
+// isIdempotentRequest returns whether the request can be retried safely.
+// As with net/http, the request that has the Idempotency-Key or X-Idempotency-Key header is treated as idempotent.
+func isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	if _, ok := req.Header["X-Idempotency-Key"]; ok {
+		return true
+	}
+	return false
+}
+
 func mergeURL(u *url.URL, p string) (*url.URL, error) {
 	if !strings.HasPrefix(p, "/") {
 		return nil, fmt.Errorf("invalid path: %s", p)
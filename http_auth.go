@@ -0,0 +1,553 @@
+package runn
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5" //#nosec G501
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	httpAuthTypeBasic  = "basic"
+	httpAuthTypeBearer = "bearer"
+	httpAuthTypeDigest = "digest"
+	httpAuthTypeOAuth2 = "oauth2"
+	httpAuthTypeSigV4  = "sigv4"
+)
+
+const (
+	oauth2GrantTypeClientCredentials = "client_credentials"
+	oauth2GrantTypePassword          = "password"
+	oauth2GrantTypeRefreshToken      = "refresh_token"
+)
+
+// oauth2TokenExpiryDelta is the margin to refresh the token before it expires.
+const oauth2TokenExpiryDelta = 10 * time.Second
+
+// oauth2Tokens is the cache of OAuth 2.0 access tokens shared across runbooks.
+var oauth2Tokens = &oauth2TokenCache{
+	entries: map[string]*oauth2TokenEntry{},
+}
+
+type httpAuth struct {
+	config *httpAuthConfig
+	digest *digestChallenge
+	nc     int
+	mu     sync.Mutex
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+type oauth2Token struct {
+	accessToken  string
+	tokenType    string
+	refreshToken string
+	expiry       time.Time
+}
+
+type oauth2TokenCache struct {
+	entries map[string]*oauth2TokenEntry
+	mu      sync.Mutex
+}
+
+// oauth2TokenEntry is the cached token of the credentials.
+// mu is held while fetching the token so that the token is fetched only once for the same credentials.
+type oauth2TokenEntry struct {
+	token *oauth2Token
+	mu    sync.Mutex
+}
+
+// entry returns the entry of the key, creating it if it does not exist.
+func (c *oauth2TokenCache) entry(key string) *oauth2TokenEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &oauth2TokenEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+func newHTTPAuth(c *httpAuthConfig) (*httpAuth, error) {
+	if c == nil {
+		return nil, nil
+	}
+	switch c.Type {
+	case httpAuthTypeBasic, httpAuthTypeDigest:
+		if c.Username == "" {
+			return nil, fmt.Errorf("auth in HttpRunnerConfig is invalid: username is required for %s", c.Type)
+		}
+	case httpAuthTypeBearer:
+		if c.Token == "" {
+			return nil, errors.New("auth in HttpRunnerConfig is invalid: token is required for bearer")
+		}
+	case httpAuthTypeOAuth2:
+		if c.TokenURL == "" {
+			return nil, errors.New("auth in HttpRunnerConfig is invalid: tokenURL is required for oauth2")
+		}
+		if c.ClientID == "" {
+			return nil, errors.New("auth in HttpRunnerConfig is invalid: clientID is required for oauth2")
+		}
+		switch c.GrantType {
+		case "":
+			c.GrantType = oauth2GrantTypeClientCredentials
+		case oauth2GrantTypeClientCredentials:
+		case oauth2GrantTypePassword:
+			if c.Username == "" {
+				return nil, errors.New("auth in HttpRunnerConfig is invalid: username is required for password grant")
+			}
+		default:
+			return nil, fmt.Errorf("auth in HttpRunnerConfig is invalid: unsupported grantType: %s", c.GrantType)
+		}
+	case httpAuthTypeSigV4:
+		if c.Region == "" || c.Service == "" {
+			return nil, errors.New("auth in HttpRunnerConfig is invalid: region and service are required for sigv4")
+		}
+	default:
+		return nil, fmt.Errorf("auth in HttpRunnerConfig is invalid: unsupported type: %s", c.Type)
+	}
+	return &httpAuth{config: c}, nil
+}
+
+// authenticate sets credentials to the request. It does nothing if the Authorization header is already set.
+func (rnr *httpRunner) authenticate(ctx context.Context, req *http.Request, body []byte) error {
+	if rnr.auth == nil || req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	// Credentials are expanded at request time so that they can refer to variables and the results of previous steps.
+	c, err := rnr.auth.expand(rnr.operator)
+	if err != nil {
+		return err
+	}
+	switch c.Type {
+	case httpAuthTypeBasic:
+		req.SetBasicAuth(c.Username, c.Password)
+	case httpAuthTypeBearer:
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	case httpAuthTypeDigest:
+		if h := rnr.auth.digestAuthorization(c, req); h != "" {
+			req.Header.Set("Authorization", h)
+		}
+	case httpAuthTypeOAuth2:
+		t, err := rnr.oauth2Token(ctx, c)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("%s %s", t.tokenType, t.accessToken))
+	case httpAuthTypeSigV4:
+		signSigV4(req, body, c, time.Now())
+	}
+	return nil
+}
+
+// challenged returns whether the request should be sent again in response to the authentication challenge (Digest).
+func (a *httpAuth) challenged(res *http.Response) bool {
+	if a == nil || a.config.Type != httpAuthTypeDigest || res.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	for _, h := range res.Header.Values("WWW-Authenticate") {
+		ch, ok := parseDigestChallenge(h)
+		if !ok {
+			continue
+		}
+		a.mu.Lock()
+		a.digest = ch
+		a.nc = 0
+		a.mu.Unlock()
+		return true
+	}
+	return false
+}
+
+func (a *httpAuth) expand(o *operator) (*httpAuthConfig, error) {
+	c := *a.config
+	for _, s := range []*string{&c.Username, &c.Password, &c.Token, &c.TokenURL, &c.ClientID, &c.ClientSecret, &c.AccessKeyID, &c.SecretAccessKey, &c.SessionToken} {
+		if !strings.Contains(*s, delimStart) {
+			continue
+		}
+		e, err := o.expandBeforeRecord(*s)
+		if err != nil {
+			return nil, err
+		}
+		*s = fmt.Sprintf("%v", e)
+	}
+	return &c, nil
+}
+
+func (a *httpAuth) digestAuthorization(c *httpAuthConfig, req *http.Request) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ch := a.digest
+	if ch == nil {
+		return ""
+	}
+	a.nc++
+	var h func() hash.Hash
+	algorithm := strings.ToUpper(ch.algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "SHA-256":
+		h = sha256.New
+	default:
+		h = md5.New
+	}
+	hx := func(s string) string {
+		d := h()
+		_, _ = io.WriteString(d, s)
+		return hex.EncodeToString(d.Sum(nil))
+	}
+	cnonce := randomHex(16)
+	nc := fmt.Sprintf("%08x", a.nc)
+	uri := req.URL.RequestURI()
+	ha1 := hx(strings.Join([]string{c.Username, ch.realm, c.Password}, ":"))
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = hx(strings.Join([]string{ha1, ch.nonce, cnonce}, ":"))
+	}
+	ha2 := hx(strings.Join([]string{req.Method, uri}, ":"))
+	var (
+		qop      string
+		response string
+	)
+	for _, q := range strings.Split(ch.qop, ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+			break
+		}
+	}
+	if qop != "" {
+		response = hx(strings.Join([]string{ha1, ch.nonce, nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = hx(strings.Join([]string{ha1, ch.nonce, ha2}, ":"))
+	}
+	params := []string{
+		fmt.Sprintf(`username="%s"`, c.Username),
+		fmt.Sprintf(`realm="%s"`, ch.realm),
+		fmt.Sprintf(`nonce="%s"`, ch.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if ch.algorithm != "" {
+		params = append(params, fmt.Sprintf("algorithm=%s", ch.algorithm))
+	}
+	if ch.opaque != "" {
+		params = append(params, fmt.Sprintf(`opaque="%s"`, ch.opaque))
+	}
+	if qop != "" {
+		params = append(params, fmt.Sprintf("qop=%s", qop), fmt.Sprintf("nc=%s", nc), fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return fmt.Sprintf("Digest %s", strings.Join(params, ", "))
+}
+
+func parseDigestChallenge(h string) (*digestChallenge, bool) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil, false
+	}
+	ch := &digestChallenge{}
+	for k, v := range parseAuthParams(rest) {
+		switch strings.ToLower(k) {
+		case "realm":
+			ch.realm = v
+		case "nonce":
+			ch.nonce = v
+		case "opaque":
+			ch.opaque = v
+		case "algorithm":
+			ch.algorithm = v
+		case "qop":
+			ch.qop = v
+		}
+	}
+	if ch.nonce == "" {
+		return nil, false
+	}
+	return ch, true
+}
+
+// parseAuthParams parses comma-separated auth-params ( key=value or key="quoted value" ).
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			return params
+		}
+		k, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return params
+		}
+		k = strings.TrimSpace(k)
+		rest = strings.TrimLeft(rest, " ")
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+					b.WriteByte(rest[i])
+					continue
+				}
+				if rest[i] == '"' {
+					break
+				}
+				b.WriteByte(rest[i])
+			}
+			v = b.String()
+			if i < len(rest) {
+				i++
+			}
+			s = rest[i:]
+		} else {
+			v, s, _ = strings.Cut(rest, ",")
+			v = strings.TrimSpace(v)
+		}
+		params[k] = v
+	}
+}
+
+// oauth2Token returns the access token from the cache, or fetches it from the token endpoint.
+func (rnr *httpRunner) oauth2Token(ctx context.Context, c *httpAuthConfig) (*oauth2Token, error) {
+	u, err := rnr.oauth2TokenURL(c)
+	if err != nil {
+		return nil, err
+	}
+	e := oauth2Tokens.entry(oauth2TokenCacheKey(u, c))
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t := e.token
+	if t != nil && (t.expiry.IsZero() || time.Now().Add(oauth2TokenExpiryDelta).Before(t.expiry)) {
+		return t, nil
+	}
+	if t != nil && t.refreshToken != "" {
+		t, err = rnr.fetchOAuth2Token(ctx, u, c, url.Values{
+			"grant_type":    {oauth2GrantTypeRefreshToken},
+			"refresh_token": {t.refreshToken},
+		})
+		if err == nil {
+			e.token = t
+			return t, nil
+		}
+		rnr.operator.Debugf("Failed to refresh OAuth 2.0 token, fetching a new token: %v\n", err)
+	}
+	values := url.Values{
+		"grant_type": {c.GrantType},
+	}
+	if c.GrantType == oauth2GrantTypePassword {
+		values.Set("username", c.Username)
+		values.Set("password", c.Password)
+	}
+	t, err = rnr.fetchOAuth2Token(ctx, u, c, values)
+	if err != nil {
+		return nil, err
+	}
+	e.token = t
+	return t, nil
+}
+
+// oauth2TokenCacheKey returns the key of the token cache.
+// The key is the hash of all the credentials so that the token is not shared between different secrets.
+func oauth2TokenCacheKey(tokenURL string, c *httpAuthConfig) string {
+	h := sha256.New()
+	for _, v := range []string{tokenURL, c.GrantType, c.ClientID, c.ClientSecret, c.Username, c.Password, strings.Join(c.Scopes, " ")} {
+		_, _ = h.Write([]byte(v))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// oauth2TokenURL returns the URL of the token endpoint.
+// A path such as `/oauth/token` is relative to the endpoint ( or served by the handler ).
+func (rnr *httpRunner) oauth2TokenURL(c *httpAuthConfig) (string, error) {
+	u := c.TokenURL
+	if strings.HasPrefix(u, "/") && rnr.endpoint != nil {
+		m, err := mergeURL(&url.URL{Scheme: rnr.endpoint.Scheme, Host: rnr.endpoint.Host}, u)
+		if err != nil {
+			return "", err
+		}
+		u = m.String()
+	}
+	return u, nil
+}
+
+func (rnr *httpRunner) fetchOAuth2Token(ctx context.Context, u string, c *httpAuthConfig, values url.Values) (*oauth2Token, error) {
+	if len(c.Scopes) > 0 {
+		values.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	body := values.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", MediaTypeApplicationFormUrlencoded)
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	client := rnr.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch OAuth 2.0 token from %s: %s: %s", c.TokenURL, res.Status, string(b))
+	}
+	tr := struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(b, &tr); err != nil {
+		return nil, fmt.Errorf("failed to parse OAuth 2.0 token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("failed to fetch OAuth 2.0 token from %s: access_token is empty", c.TokenURL)
+	}
+	t := &oauth2Token{
+		accessToken:  tr.AccessToken,
+		tokenType:    tr.TokenType,
+		refreshToken: tr.RefreshToken,
+	}
+	if t.tokenType == "" || strings.EqualFold(t.tokenType, "bearer") {
+		t.tokenType = "Bearer"
+	}
+	if tr.ExpiresIn > 0 {
+		t.expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+// signSigV4 signs the request with AWS Signature Version 4.
+func signSigV4(req *http.Request, body []byte, c *httpAuthConfig, now time.Time) {
+	const algorithm = "AWS4-HMAC-SHA256"
+	accessKeyID := c.AccessKeyID
+	secretAccessKey := c.SecretAccessKey
+	sessionToken := c.SessionToken
+	if accessKeyID == "" && secretAccessKey == "" {
+		accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+	if c.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{
+		"host": host,
+	}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.Join(v, ",")
+		}
+	}
+	var signed []string
+	for k := range headers {
+		signed = append(signed, k)
+	}
+	sort.Strings(signed)
+	var canonicalHeaders strings.Builder
+	for _, k := range signed {
+		canonicalHeaders.WriteString(fmt.Sprintf("%s:%s\n", k, strings.Join(strings.Fields(headers[k]), " ")))
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	p := req.URL.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		p,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, c.Region, c.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	k := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	k = hmacSHA256(k, c.Region)
+	k = hmacSHA256(k, c.Service)
+	k = hmacSHA256(k, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(k, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(q url.Values) string {
+	var keys []string
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		vs := append([]string{}, q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			params = append(params, fmt.Sprintf("%s=%s", sigV4Escape(k), sigV4Escape(v)))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
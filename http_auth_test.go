@@ -0,0 +1,263 @@
+package runn
+
+import (
+	"context"
+	"crypto/md5" //#nosec G501
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewHTTPAuth(t *testing.T) {
+	tests := []struct {
+		in      *httpAuthConfig
+		wantErr bool
+	}{
+		{nil, false},
+		{&httpAuthConfig{Type: "basic", Username: "alice", Password: "pass"}, false},
+		{&httpAuthConfig{Type: "basic"}, true},
+		{&httpAuthConfig{Type: "bearer", Token: "{{ vars.token }}"}, false},
+		{&httpAuthConfig{Type: "bearer"}, true},
+		{&httpAuthConfig{Type: "digest", Username: "alice"}, false},
+		{&httpAuthConfig{Type: "oauth2", TokenURL: "https://auth.example.com/token", ClientID: "client"}, false},
+		{&httpAuthConfig{Type: "oauth2", TokenURL: "https://auth.example.com/token", ClientID: "client", GrantType: "password"}, true},
+		{&httpAuthConfig{Type: "oauth2", TokenURL: "https://auth.example.com/token", ClientID: "client", GrantType: "implicit"}, true},
+		{&httpAuthConfig{Type: "oauth2", ClientID: "client"}, true},
+		{&httpAuthConfig{Type: "sigv4", Region: "us-east-1", Service: "execute-api"}, false},
+		{&httpAuthConfig{Type: "sigv4", Region: "us-east-1"}, true},
+		{&httpAuthConfig{Type: "ntlm"}, true},
+	}
+	for _, tt := range tests {
+		_, err := newHTTPAuth(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%#v: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSignSigV4(t *testing.T) {
+	// ref: AWS Signature Version 4 test suite ( get-vanilla )
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &httpAuthConfig{
+		Type:            httpAuthTypeSigV4,
+		Region:          "us-east-1",
+		Service:         "service",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	signSigV4(req, nil, c, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestParseAuthParams(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+	}{
+		{
+			`realm="runn", qop="auth,auth-int", nonce="abc", algorithm=MD5`,
+			map[string]string{"realm": "runn", "qop": "auth,auth-int", "nonce": "abc", "algorithm": "MD5"},
+		},
+		{
+			`realm="a \"quoted\" realm",nonce=xyz`,
+			map[string]string{"realm": `a "quoted" realm`, "nonce": "xyz"},
+		},
+		{
+			``,
+			map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		got := parseAuthParams(tt.in)
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestHTTPRunnerAuth(t *testing.T) {
+	var tokenRequests atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/basic", func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != "alice" || p != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/bearer", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xxxxx" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/digest", func(w http.ResponseWriter, r *http.Request) {
+		const (
+			realm = "runn"
+			nonce = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+		)
+		h := r.Header.Get("Authorization")
+		if !strings.HasPrefix(h, "Digest ") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth", nonce="%s", opaque="5ccc069c403ebaf9f0171e9517f40e41"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := parseAuthParams(strings.TrimPrefix(h, "Digest "))
+		hx := func(s string) string {
+			d := md5.Sum([]byte(s)) //#nosec G401
+			return hex.EncodeToString(d[:])
+		}
+		ha1 := hx(fmt.Sprintf("%s:%s:%s", "alice", realm, "pass"))
+		ha2 := hx(fmt.Sprintf("%s:%s", r.Method, p["uri"]))
+		want := hx(strings.Join([]string{ha1, nonce, p["nc"], p["cnonce"], p["qop"], ha2}, ":"))
+		if p["username"] != "alice" || p["response"] != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		id, secret, ok := r.BasicAuth()
+		if !ok || !strings.HasPrefix(id, "client") || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Form.Get("grant_type") {
+		case "client_credentials":
+		case "password":
+			if r.Form.Get("username") != "alice" || r.Form.Get("password") != "pass" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"at-runn","token_type":"bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc("/oauth2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at-runn" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		ts.Close()
+	})
+
+	tests := []struct {
+		name              string
+		path              string
+		auth              *httpAuthConfig
+		wantTokenRequests int64
+	}{
+		{"basic", "/basic", &httpAuthConfig{Type: "basic", Username: "alice", Password: "pass"}, 0},
+		{"bearer", "/bearer", &httpAuthConfig{Type: "bearer", Token: "{{ vars.token }}"}, 0},
+		{"digest", "/digest", &httpAuthConfig{Type: "digest", Username: "alice", Password: "pass"}, 0},
+		{"oauth2 client credentials", "/oauth2", &httpAuthConfig{Type: "oauth2", TokenURL: "/token", ClientID: "client-cc", ClientSecret: "secret"}, 1},
+		{"oauth2 password", "/oauth2", &httpAuthConfig{Type: "oauth2", GrantType: "password", TokenURL: "/token", ClientID: "client-password", ClientSecret: "secret", Username: "alice", Password: "pass"}, 1},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		for _, useHandler := range []bool{false, true} {
+			tt := tt
+			useHandler := useHandler
+			t.Run(fmt.Sprintf("%s (handler: %v)", tt.name, useHandler), func(t *testing.T) {
+				tokenRequests.Store(0)
+				o, err := New(Var("token", "xxxxx"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var r *httpRunner
+				if useHandler {
+					r, err = newHTTPRunnerWithHandler("req", mux)
+				} else {
+					r, err = newHTTPRunner("req", ts.URL)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.operator = o
+				c := *tt.auth
+				if c.ClientID != "" {
+					// Avoid sharing cached tokens between subtests
+					c.ClientID = fmt.Sprintf("%s-%v", c.ClientID, useHandler)
+				}
+				r.auth, err = newHTTPAuth(&c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Send twice to use cached credentials.
+				for i := 0; i < 2; i++ {
+					req := &httpRequest{
+						path:    tt.path,
+						method:  http.MethodGet,
+						headers: map[string]string{},
+					}
+					if err := r.Run(ctx, req); err != nil {
+						t.Fatal(err)
+					}
+					if got := o.store.latest()["res"].(map[string]any)["status"]; got != http.StatusOK {
+						t.Errorf("got %v\nwant %v", got, http.StatusOK)
+					}
+				}
+				if got := tokenRequests.Load(); got != tt.wantTokenRequests {
+					t.Errorf("got %v\nwant %v", got, tt.wantTokenRequests)
+				}
+			})
+		}
+	}
+}
+
+func TestOAuth2TokenCacheKey(t *testing.T) {
+	base := httpAuthConfig{Type: "oauth2", GrantType: "password", TokenURL: "/token", ClientID: "client", ClientSecret: "secret", Username: "alice", Password: "pass"}
+	tests := []struct {
+		name     string
+		tokenURL string
+		modify   func(c *httpAuthConfig)
+		wantSame bool
+	}{
+		{"same credentials", "https://a.example.com/token", func(c *httpAuthConfig) {}, true},
+		{"different client secret", "https://a.example.com/token", func(c *httpAuthConfig) { c.ClientSecret = "other" }, false},
+		{"different password", "https://a.example.com/token", func(c *httpAuthConfig) { c.Password = "other" }, false},
+		{"different scopes", "https://a.example.com/token", func(c *httpAuthConfig) { c.Scopes = []string{"read"} }, false},
+		{"different token endpoint", "https://b.example.com/token", func(c *httpAuthConfig) {}, false},
+	}
+	want := oauth2TokenCacheKey("https://a.example.com/token", &base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := base
+			tt.modify(&c)
+			got := oauth2TokenCacheKey(tt.tokenURL, &c)
+			if (got == want) != tt.wantSame {
+				t.Errorf("got %v\nwant same: %v", got, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	contentEncodingGzip     = "gzip"
+	contentEncodingDeflate  = "deflate"
+	contentEncodingBrotli   = "br"
+	contentEncodingZstd     = "zstd"
+	contentEncodingIdentity = "identity"
+)
+
+// supportedContentEncodings are the content codings that the HTTP runner can compress and decompress.
+var supportedContentEncodings = []string{contentEncodingGzip, contentEncodingDeflate, contentEncodingBrotli, contentEncodingZstd}
+
+func isSupportedContentEncoding(enc string) bool {
+	for _, e := range supportedContentEncodings {
+		if enc == e {
+			return true
+		}
+	}
+	return false
+}
+
+// compressBody compresses the request body with the content coding.
+func compressBody(enc string, body io.Reader) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	var w io.WriteCloser
+	switch enc {
+	case contentEncodingGzip:
+		w = gzip.NewWriter(buf)
+	case contentEncodingDeflate:
+		w = zlib.NewWriter(buf)
+	case contentEncodingBrotli:
+		w = brotli.NewWriter(buf)
+	case contentEncodingZstd:
+		zw, err := zstd.NewWriter(buf)
+		if err != nil {
+			return nil, err
+		}
+		w = zw
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", enc)
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// decompressResponse replaces the body of the response with the decompressed body and returns the original Content-Encoding.
+// If the response has been decompressed by the transport, it returns "gzip".
+// If the Content-Encoding contains unsupported content codings, the response is left as it is.
+func decompressResponse(res *http.Response) (string, error) {
+	if res.Uncompressed {
+		return contentEncodingGzip, nil
+	}
+	orig := res.Header.Get("Content-Encoding")
+	var encs []string
+	for _, e := range strings.Split(orig, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || e == contentEncodingIdentity {
+			continue
+		}
+		if !isSupportedContentEncoding(e) {
+			return orig, nil
+		}
+		encs = append(encs, e)
+	}
+	if len(encs) == 0 || res.Body == nil || res.Body == http.NoBody {
+		return orig, nil
+	}
+	body := res.Body
+	var r io.Reader = body
+	closers := []io.Closer{body}
+	// Content codings are applied in the order listed, so decode in reverse order
+	for i := len(encs) - 1; i >= 0; i-- {
+		br := bufio.NewReader(r)
+		if _, err := br.Peek(1); err != nil {
+			if errors.Is(err, io.EOF) {
+				// Empty body ( e.g. HEAD request )
+				r = br
+				break
+			}
+			return "", err
+		}
+		dr, err := newDecompressReader(encs[i], br)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress response body (%s): %w", encs[i], err)
+		}
+		if c, ok := dr.(io.Closer); ok {
+			closers = append(closers, c)
+		}
+		r = dr
+	}
+	res.Body = &decompressedBody{Reader: r, closers: closers}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return orig, nil
+}
+
+func newDecompressReader(enc string, br *bufio.Reader) (io.Reader, error) {
+	switch enc {
+	case contentEncodingGzip:
+		return gzip.NewReader(br)
+	case contentEncodingDeflate:
+		// Some servers send raw DEFLATE without the zlib wrapper
+		h, err := br.Peek(2)
+		if err == nil && h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case contentEncodingBrotli:
+		return brotli.NewReader(br), nil
+	case contentEncodingZstd:
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported content encoding: %s", enc)
+}
+
+// decompressedBody is the decompressed response body that closes the decompressors and the original body.
+type decompressedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decompressedBody) Close() error {
+	var err error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if cerr := b.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
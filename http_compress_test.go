@@ -0,0 +1,180 @@
+package runn
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHTTPRunnerDecompressResponse(t *testing.T) {
+	const body = `{"message":"hello"}`
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := r.URL.Query().Get("enc")
+		var b []byte
+		switch enc {
+		case "raw-deflate":
+			buf := new(bytes.Buffer)
+			fw, err := flate.NewWriter(buf, flate.DefaultCompression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = fw.Write([]byte(body))
+			_ = fw.Close()
+			b = buf.Bytes()
+			enc = contentEncodingDeflate
+		case "":
+			if strings.Contains(r.Header.Get("Accept-Encoding"), contentEncodingGzip) {
+				enc = contentEncodingGzip
+			}
+			fallthrough
+		default:
+			var rd io.Reader = strings.NewReader(body)
+			for _, e := range strings.Split(enc, ",") {
+				e = strings.TrimSpace(e)
+				if e == "" || e == contentEncodingIdentity {
+					continue
+				}
+				var err error
+				rd, err = compressBody(e, rd)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			var err error
+			b, err = io.ReadAll(rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Header().Set("Content-Type", MediaTypeApplicationJSON)
+		if enc != "" {
+			w.Header().Set("Content-Encoding", enc)
+		}
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(hs.Close)
+
+	tests := []struct {
+		name                string
+		method              string
+		enc                 string
+		acceptEncoding      string
+		wantContentEncoding string
+		wantBody            any
+	}{
+		{"decompressed by transport", http.MethodGet, "", "", "gzip", map[string]any{"message": "hello"}},
+		{"gzip", http.MethodGet, "gzip", "gzip", "gzip", map[string]any{"message": "hello"}},
+		{"deflate", http.MethodGet, "deflate", "deflate", "deflate", map[string]any{"message": "hello"}},
+		{"raw deflate", http.MethodGet, "raw-deflate", "deflate", "deflate", map[string]any{"message": "hello"}},
+		{"br", http.MethodGet, "br", "br", "br", map[string]any{"message": "hello"}},
+		{"zstd", http.MethodGet, "zstd", "zstd", "zstd", map[string]any{"message": "hello"}},
+		{"multiple encodings", http.MethodGet, "gzip, br", "gzip, br", "gzip, br", map[string]any{"message": "hello"}},
+		{"identity", http.MethodGet, "identity", "identity", "identity", map[string]any{"message": "hello"}},
+		{"empty body", http.MethodHead, "zstd", "zstd", "zstd", nil},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			req := &httpRequest{
+				path:    "/?enc=" + tt.enc,
+				method:  tt.method,
+				headers: map[string]string{},
+			}
+			if tt.acceptEncoding != "" {
+				req.headers["Accept-Encoding"] = tt.acceptEncoding
+			}
+			if err := r.Run(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			if got := res["contentEncoding"]; got != tt.wantContentEncoding {
+				t.Errorf("got %v\nwant %v", got, tt.wantContentEncoding)
+			}
+			if diff := cmp.Diff(res["body"], tt.wantBody); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerCompressRequest(t *testing.T) {
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := &http.Response{
+			Header: http.Header{"Content-Encoding": r.Header.Values("Content-Encoding")},
+			Body:   r.Body,
+		}
+		enc, err := decompressResponse(res)
+		if err != nil {
+			t.Error(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Request-Content-Encoding", enc)
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(hs.Close)
+
+	for _, enc := range supportedContentEncodings {
+		t.Run(enc, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			req := &httpRequest{
+				path:      "/upload",
+				method:    http.MethodPost,
+				headers:   map[string]string{},
+				mediaType: MediaTypeApplicationJSON,
+				body:      map[string]any{"message": "hello"},
+				compress:  enc,
+			}
+			if err := r.Run(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			h, ok := res["headers"].(http.Header)
+			if !ok {
+				t.Fatalf("invalid headers: %#v", res["headers"])
+			}
+			if got := h.Get("X-Request-Content-Encoding"); got != enc {
+				t.Errorf("got %v\nwant %v", got, enc)
+			}
+			if diff := cmp.Diff(res["body"], map[string]any{"message": "hello"}); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	xmlAttrPrefix = "-"
+	xmlTextKey    = "#text"
+)
+
+// HTTPResponseBodyDecoder decodes the HTTP response body into a value that can be used in expressions.
+type HTTPResponseBodyDecoder func(b []byte) (any, error)
+
+var (
+	httpResponseBodyDecoders = map[string]HTTPResponseBodyDecoder{
+		MediaTypeApplicationJSON:           decodeJSONBody,
+		MediaTypeApplicationXML:            decodeXMLBody,
+		MediaTypeTextXML:                   decodeXMLBody,
+		MediaTypeApplicationYAML:           decodeYAMLBody,
+		"application/x-yaml":               decodeYAMLBody,
+		"text/yaml":                        decodeYAMLBody,
+		MediaTypeApplicationFormUrlencoded: decodeFormUrlencodedBody,
+		MediaTypeTextCSV:                   decodeCSVBody,
+	}
+	httpResponseBodyDecodersMu sync.RWMutex
+)
+
+// RegisterHTTPResponseBodyDecoder registers the decoder of HTTP response body for the media type.
+func RegisterHTTPResponseBodyDecoder(mediaType string, fn HTTPResponseBodyDecoder) {
+	httpResponseBodyDecodersMu.Lock()
+	defer httpResponseBodyDecodersMu.Unlock()
+	httpResponseBodyDecoders[strings.ToLower(mediaType)] = fn
+}
+
+// UnregisterHTTPResponseBodyDecoder unregisters the decoder of HTTP response body for the media type.
+func UnregisterHTTPResponseBodyDecoder(mediaType string) {
+	httpResponseBodyDecodersMu.Lock()
+	defer httpResponseBodyDecodersMu.Unlock()
+	delete(httpResponseBodyDecoders, strings.ToLower(mediaType))
+}
+
+func findHTTPResponseBodyDecoder(contentType string) (HTTPResponseBodyDecoder, bool) {
+	mt := baseMediaType(contentType)
+	httpResponseBodyDecodersMu.RLock()
+	defer httpResponseBodyDecodersMu.RUnlock()
+	if fn, ok := httpResponseBodyDecoders[mt]; ok {
+		return fn, true
+	}
+	// Structured syntax suffix (RFC 6839) e.g. application/problem+json, application/soap+xml
+	switch {
+	case strings.Contains(mt, "json"):
+		return httpResponseBodyDecoders[MediaTypeApplicationJSON], true
+	case strings.HasSuffix(mt, "+xml"):
+		return httpResponseBodyDecoders[MediaTypeApplicationXML], true
+	case strings.HasSuffix(mt, "+yaml"):
+		return httpResponseBodyDecoders[MediaTypeApplicationYAML], true
+	}
+	return nil, false
+}
+
+// decodeHTTPResponseBody decodes the HTTP response body using the decoder registered for the Content-Type.
+// If no decoder is found or body is empty, it returns nil.
+func decodeHTTPResponseBody(contentType string, b []byte) (any, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	fn, ok := findHTTPResponseBodyDecoder(contentType)
+	if !ok || fn == nil {
+		return nil, nil
+	}
+	v, err := fn(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response body (%s): %w", contentType, err)
+	}
+	return v, nil
+}
+
+// isStrictHTTPResponseBodyDecoding reports whether the step fails when the response body cannot be decoded.
+// Only JSON is decoded strictly. For the other media types, only rawBody is recorded.
+func isStrictHTTPResponseBodyDecoding(contentType string) bool {
+	return strings.Contains(baseMediaType(contentType), "json")
+}
+
+func decodeJSONBody(b []byte) (any, error) {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func decodeYAMLBody(b []byte) (any, error) {
+	var v any
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	// To match behavior with json.Unmarshal
+	jb, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONBody(jb)
+}
+
+func decodeFormUrlencodedBody(b []byte) (any, error) {
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	for k, vs := range values {
+		if len(vs) == 1 {
+			m[k] = vs[0]
+			continue
+		}
+		var l []any
+		for _, v := range vs {
+			l = append(l, v)
+		}
+		m[k] = l
+	}
+	return m, nil
+}
+
+func decodeCSVBody(b []byte) (any, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []any{}
+	for _, record := range records {
+		row := []any{}
+		for _, f := range record {
+			row = append(row, f)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeXMLBody decodes XML into map.
+// Attributes are stored with the "-" prefix, and the text of an element that has attributes or child elements is stored as "#text".
+// Repeated child elements are stored as a list.
+func decodeXMLBody(b []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	dec.Strict = false
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("no root element")
+			}
+			return nil, err
+		}
+		if se, ok := t.(xml.StartElement); ok {
+			v, err := decodeXMLElement(dec, se)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{se.Name.Local: v}, nil
+		}
+	}
+}
+
+func decodeXMLElement(dec *xml.Decoder, se xml.StartElement) (any, error) {
+	m := map[string]any{}
+	for _, a := range se.Attr {
+		m[xmlAttrPrefix+a.Name.Local] = a.Value
+	}
+	var text strings.Builder
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			v, err := decodeXMLElement(dec, tt)
+			if err != nil {
+				return nil, err
+			}
+			k := tt.Name.Local
+			ev, ok := m[k]
+			if !ok {
+				m[k] = v
+				continue
+			}
+			if l, ok := ev.([]any); ok {
+				m[k] = append(l, v)
+			} else {
+				m[k] = []any{ev, v}
+			}
+		case xml.CharData:
+			text.Write(tt)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if len(m) == 0 {
+				return s, nil
+			}
+			if s != "" {
+				m[xmlTextKey] = s
+			}
+			return m, nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeHTTPResponseBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		in          string
+		want        any
+	}{
+		{
+			"application/json",
+			`{"data":{"username":"alice"}}`,
+			map[string]any{"data": map[string]any{"username": "alice"}},
+		},
+		{
+			"application/problem+json; charset=utf-8",
+			`{"title":"Not Found","status":404}`,
+			map[string]any{"title": "Not Found", "status": float64(404)},
+		},
+		{
+			"application/json",
+			``,
+			nil,
+		},
+		{
+			"application/xml",
+			`<?xml version="1.0" encoding="UTF-8"?>
+<users count="2">
+  <user id="1"><name>alice</name></user>
+  <user id="2"><name>bob</name></user>
+</users>`,
+			map[string]any{"users": map[string]any{
+				"-count": "2",
+				"user": []any{
+					map[string]any{"-id": "1", "name": "alice"},
+					map[string]any{"-id": "2", "name": "bob"},
+				},
+			}},
+		},
+		{
+			"application/soap+xml",
+			`<Envelope><Body><Result lang="en">ok</Result></Body></Envelope>`,
+			map[string]any{"Envelope": map[string]any{
+				"Body": map[string]any{
+					"Result": map[string]any{"-lang": "en", "#text": "ok"},
+				},
+			}},
+		},
+		{
+			"application/yaml",
+			`data:
+  username: alice
+  age: 20`,
+			map[string]any{"data": map[string]any{"username": "alice", "age": float64(20)}},
+		},
+		{
+			"application/x-www-form-urlencoded",
+			`one=ichi&two=ni&two=%E4%BA%8C`,
+			map[string]any{"one": "ichi", "two": []any{"ni", "二"}},
+		},
+		{
+			"text/csv; charset=utf-8",
+			"id,name\n1,alice\n2,\"bob, jr.\"\n",
+			[]any{
+				[]any{"id", "name"},
+				[]any{"1", "alice"},
+				[]any{"2", "bob, jr."},
+			},
+		},
+		{
+			"text/html; charset=utf-8",
+			`<h1>Hello</h1>`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, err := decodeHTTPResponseBody(tt.contentType, []byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRegisterHTTPResponseBodyDecoder(t *testing.T) {
+	const mt = "application/x-runn-test"
+	RegisterHTTPResponseBodyDecoder(mt, func(b []byte) (any, error) {
+		return map[string]any{"length": len(b)}, nil
+	})
+	t.Cleanup(func() {
+		UnregisterHTTPResponseBodyDecoder(mt)
+	})
+	got, err := decodeHTTPResponseBody(mt, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"length": 5}
+	if diff := cmp.Diff(got, want, nil); diff != "" {
+		t.Error(diff)
+	}
+}
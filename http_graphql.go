@@ -0,0 +1,167 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"go.uber.org/multierr"
+)
+
+const (
+	graphqlQueryKey         = "query"
+	graphqlVariablesKey     = "variables"
+	graphqlOperationNameKey = "operationName"
+
+	httpStoreGraphQLDataKey   = "data"
+	httpStoreGraphQLErrorsKey = "errors"
+)
+
+// graphqlQueryFileExts are the extensions of files that are read as GraphQL queries.
+var graphqlQueryFileExts = []string{".graphql", ".gql"}
+
+// httpGraphQL is the GraphQL request sent by the HTTP runner.
+type httpGraphQL struct {
+	// query is the GraphQL query or the path of the file ( *.graphql, *.gql ) containing the query.
+	query         string
+	variables     map[string]any
+	operationName string
+}
+
+func parseHTTPGraphQL(v any) (*httpGraphQL, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid graphql: %v", v)
+	}
+	g := &httpGraphQL{}
+	for k, vv := range m {
+		switch k {
+		case graphqlQueryKey:
+			g.query, ok = vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid graphql query: %v", vv)
+			}
+		case graphqlVariablesKey:
+			if vv == nil {
+				continue
+			}
+			g.variables, ok = vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid graphql variables: %v", vv)
+			}
+		case graphqlOperationNameKey:
+			g.operationName, ok = vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid graphql operationName: %v", vv)
+			}
+		default:
+			return nil, fmt.Errorf("invalid graphql: unknown key: %s", k)
+		}
+	}
+	if strings.TrimSpace(g.query) == "" {
+		return nil, errors.New("invalid graphql: query is required")
+	}
+	return g, nil
+}
+
+// isQueryFile returns whether the query is the path of the file containing the query.
+func (g *httpGraphQL) isQueryFile() bool {
+	if strings.ContainsAny(g.query, "{\n") {
+		return false
+	}
+	ext := filepath.Ext(g.query)
+	for _, e := range graphqlQueryFileExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// loadQuery returns the GraphQL query. The query file is read from the root directory of the runbook.
+func (g *httpGraphQL) loadQuery(root string) (string, error) {
+	if !g.isQueryFile() {
+		return g.query, nil
+	}
+	b, err := readFile(filepath.Join(root, g.query))
+	if err != nil {
+		return "", fmt.Errorf("failed to read graphql query: %w", err)
+	}
+	return string(b), nil
+}
+
+// setGraphQL sets the GraphQL request to the body ( POST ) or the query parameters ( GET ) of the request.
+func (r *httpRequest) setGraphQL() error {
+	if r.graphql == nil {
+		return nil
+	}
+	q, err := r.graphql.loadQuery(r.root)
+	if err != nil {
+		return err
+	}
+	if r.method == http.MethodGet {
+		if r.query == nil {
+			r.query = url.Values{}
+		}
+		r.query.Set(graphqlQueryKey, q)
+		if len(r.graphql.variables) > 0 {
+			b, err := json.Marshal(r.graphql.variables)
+			if err != nil {
+				return err
+			}
+			r.query.Set(graphqlVariablesKey, string(b))
+		}
+		if r.graphql.operationName != "" {
+			r.query.Set(graphqlOperationNameKey, r.graphql.operationName)
+		}
+		return nil
+	}
+	body := map[string]any{
+		graphqlQueryKey: q,
+	}
+	if len(r.graphql.variables) > 0 {
+		body[graphqlVariablesKey] = r.graphql.variables
+	}
+	if r.graphql.operationName != "" {
+		body[graphqlOperationNameKey] = r.graphql.operationName
+	}
+	r.mediaType = MediaTypeApplicationJSON
+	r.body = body
+	return nil
+}
+
+// graphqlResult returns `data` and `errors` of the GraphQL response body.
+func graphqlResult(body any) (any, []any) {
+	m, ok := body.(map[string]any)
+	if !ok {
+		return nil, []any{}
+	}
+	errs, ok := m[httpStoreGraphQLErrorsKey].([]any)
+	if !ok {
+		errs = []any{}
+	}
+	return m[httpStoreGraphQLDataKey], errs
+}
+
+// graphqlError returns the error containing messages of the GraphQL errors.
+func graphqlError(errs []any) error {
+	var merr error
+	for _, e := range errs {
+		m, ok := e.(map[string]any)
+		if !ok {
+			merr = multierr.Append(merr, fmt.Errorf("%v", e))
+			continue
+		}
+		msg, ok := m["message"].(string)
+		if !ok {
+			merr = multierr.Append(merr, fmt.Errorf("%v", e))
+			continue
+		}
+		merr = multierr.Append(merr, errors.New(msg))
+	}
+	return fmt.Errorf("graphql errors: %w", merr)
+}
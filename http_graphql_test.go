@@ -0,0 +1,157 @@
+package runn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHTTPRunnerGraphQL(t *testing.T) {
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			query     string
+			variables map[string]any
+		)
+		switch r.Method {
+		case http.MethodGet:
+			query = r.URL.Query().Get("query")
+			if v := r.URL.Query().Get("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &variables); err != nil {
+					t.Error(err)
+				}
+			}
+		default:
+			if ct := r.Header.Get("Content-Type"); ct != MediaTypeApplicationJSON {
+				t.Errorf("got %v\nwant %v", ct, MediaTypeApplicationJSON)
+			}
+			var body struct {
+				Query     string         `json:"query"`
+				Variables map[string]any `json:"variables"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			query, variables = body.Query, body.Variables
+		}
+		w.Header().Set("Content-Type", MediaTypeApplicationJSON)
+		if variables["id"] != "1" {
+			_, _ = w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"user not found"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"user":{"name":"alice","query":` + mustJSONString(t, query) + `}}}`))
+	}))
+	t.Cleanup(hs.Close)
+
+	root := t.TempDir()
+	fileQuery := "query GetUser($id: ID!) {\n  user(id: $id) { name }\n}\n"
+	if err := os.WriteFile(filepath.Join(root, "user.graphql"), []byte(fileQuery), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	inlineQuery := "query GetUser($id: ID!) { user(id: $id) { name } }"
+
+	tests := []struct {
+		name         string
+		method       string
+		graphql      *httpGraphQL
+		failOnErrors bool
+		wantData     any
+		wantErrors   []any
+		wantErr      bool
+	}{
+		{
+			"POST inline query",
+			http.MethodPost,
+			&httpGraphQL{query: inlineQuery, variables: map[string]any{"id": "1"}},
+			false,
+			map[string]any{"user": map[string]any{"name": "alice", "query": inlineQuery}},
+			[]any{},
+			false,
+		},
+		{
+			"POST query file",
+			http.MethodPost,
+			&httpGraphQL{query: "user.graphql", variables: map[string]any{"id": "1"}, operationName: "GetUser"},
+			false,
+			map[string]any{"user": map[string]any{"name": "alice", "query": fileQuery}},
+			[]any{},
+			false,
+		},
+		{
+			"GET inline query",
+			http.MethodGet,
+			&httpGraphQL{query: inlineQuery, variables: map[string]any{"id": "1"}},
+			false,
+			map[string]any{"user": map[string]any{"name": "alice", "query": inlineQuery}},
+			[]any{},
+			false,
+		},
+		{
+			"errors are recorded",
+			http.MethodPost,
+			&httpGraphQL{query: inlineQuery, variables: map[string]any{"id": "2"}},
+			false,
+			map[string]any{"user": nil},
+			[]any{map[string]any{"message": "user not found"}},
+			false,
+		},
+		{
+			"fail on errors",
+			http.MethodPost,
+			&httpGraphQL{query: inlineQuery, variables: map[string]any{"id": "2"}},
+			true,
+			map[string]any{"user": nil},
+			[]any{map[string]any{"message": "user not found"}},
+			true,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.root = root
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			r.failOnGraphQLErrors = tt.failOnErrors
+			req := &httpRequest{
+				path:    "/graphql",
+				method:  tt.method,
+				headers: map[string]string{},
+				graphql: tt.graphql,
+			}
+			if err := r.Run(ctx, req); (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			if diff := cmp.Diff(res["data"], tt.wantData); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(res["errors"], tt.wantErrors); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func mustJSONString(t *testing.T, s string) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,195 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+	"github.com/k1LoW/duration"
+)
+
+const (
+	sseEventIDKey      = "id"
+	sseEventTypeKey    = "event"
+	sseEventDataKey    = "data"
+	sseEventRawDataKey = "rawData"
+	sseEventRetryKey   = "retry"
+
+	sseStoreEventKey  = "event"
+	sseStoreEventsKey = "events"
+
+	defaultSSEEventType = "message"
+)
+
+// httpSSE is the options to stop reading Server-Sent Events.
+type httpSSE struct {
+	Count   int    `yaml:"count,omitempty"`
+	Until   string `yaml:"until,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+
+	timeout time.Duration
+}
+
+func parseHTTPSSE(v any) (*httpSSE, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := &httpSSE{}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("invalid sse: %s: %w", string(b), err)
+	}
+	if s.Count < 0 {
+		return nil, fmt.Errorf("invalid sse: count must be greater than or equal to 0: %d", s.Count)
+	}
+	if s.Timeout != "" {
+		s.timeout, err = duration.Parse(s.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sse: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func isEventStream(res *http.Response) bool {
+	return baseMediaType(res.Header.Get("Content-Type")) == MediaTypeTextEventStream
+}
+
+// readEvents reads Server-Sent Events from the response body until the stream ends or one of the stop options is satisfied.
+// It returns the raw body read and the parsed events.
+// If the timeout of the runner or the step is reached while reading, the events read so far are returned.
+func (rnr *httpRunner) readEvents(res *http.Response, opt *httpSSE) ([]byte, []any, error) {
+	if opt == nil {
+		opt = &httpSSE{}
+	}
+	var timedOut atomic.Bool
+	if opt.timeout > 0 {
+		t := time.AfterFunc(opt.timeout, func() {
+			timedOut.Store(true)
+			_ = res.Body.Close()
+		})
+		defer t.Stop()
+	}
+	raw := &bytes.Buffer{}
+	br := bufio.NewReader(io.TeeReader(res.Body, raw))
+	events := []any{}
+	var (
+		id      string
+		evType  string
+		data    []string
+		retry   *int
+		hasData bool
+	)
+	dispatch := func() (bool, error) {
+		defer func() {
+			evType = ""
+			data = nil
+			retry = nil
+			hasData = false
+		}()
+		if !hasData {
+			return false, nil
+		}
+		ev := newSSEEvent(id, evType, strings.Join(data, "\n"), retry)
+		events = append(events, ev)
+		if opt.Count > 0 && len(events) >= opt.Count {
+			return true, nil
+		}
+		if opt.Until != "" {
+			store := rnr.operator.store.toMap()
+			store[sseStoreEventKey] = ev
+			store[sseStoreEventsKey] = events
+			tf, err := EvalCond(opt.Until, store)
+			if err != nil {
+				return false, err
+			}
+			if tf {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !timedOut.Load() && !isTimeoutError(err) {
+				return nil, nil, err
+			}
+			// The end of the stream. Pending data that is not terminated by a blank line is discarded.
+			return raw.Bytes(), events, nil
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			stop, err := dispatch()
+			if err != nil {
+				return nil, nil, err
+			}
+			if stop {
+				return raw.Bytes(), events, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			evType = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil {
+				retry = &n
+			}
+		}
+	}
+}
+
+// isTimeoutError returns whether the error is caused by the timeout of the client or the deadline of the context.
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+func newSSEEvent(id, evType, data string, retry *int) map[string]any {
+	if evType == "" {
+		evType = defaultSSEEventType
+	}
+	ev := map[string]any{
+		sseEventIDKey:      id,
+		sseEventTypeKey:    evType,
+		sseEventDataKey:    data,
+		sseEventRawDataKey: data,
+	}
+	if retry != nil {
+		ev[sseEventRetryKey] = *retry
+	}
+	trimmed := strings.TrimSpace(data)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var v any
+		if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+			ev[sseEventDataKey] = v
+		}
+	}
+	return ev
+}
@@ -0,0 +1,117 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHTTPRunnerSSE(t *testing.T) {
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("could not flush")
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, ": comment\n\n")
+		for i := 1; i <= 3; i++ {
+			_, _ = fmt.Fprintf(w, "id: %d\nevent: progress\ndata: {\"n\": %d,\ndata: \"done\": %t}\n\n", i, i, i == 3)
+			f.Flush()
+		}
+		_, _ = fmt.Fprint(w, "retry: 1000\ndata: hello\r\n\r\n")
+		f.Flush()
+		if r.URL.Path == "/endless" {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(hs.Close)
+
+	tests := []struct {
+		path          string
+		sse           *httpSSE
+		clientTimeout time.Duration
+		want          int
+		wantErr       bool
+	}{
+		{"/", nil, 0, 4, false},
+		{"/", &httpSSE{Count: 2}, 0, 2, false},
+		{"/", &httpSSE{Until: "event.data.done == true"}, 0, 3, false},
+		{"/", &httpSSE{Until: "len(events) == 4"}, 0, 4, false},
+		{"/endless", &httpSSE{Count: 4}, 0, 4, false},
+		{"/endless", &httpSSE{timeout: 100 * time.Millisecond}, 0, 4, false},
+		{"/endless", nil, 200 * time.Millisecond, 4, false},
+		{"/", &httpSSE{Until: "invalid("}, 0, 0, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("%s %#v %s", tt.path, tt.sse, tt.clientTimeout), func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			if tt.clientTimeout > 0 {
+				r.client.Timeout = tt.clientTimeout
+			}
+			req := &httpRequest{
+				path:    tt.path,
+				method:  http.MethodGet,
+				headers: map[string]string{},
+				sse:     tt.sse,
+			}
+			if err := r.Run(ctx, req); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			events, ok := res["events"].([]any)
+			if !ok {
+				t.Fatalf("invalid events: %#v", res["events"])
+			}
+			if len(events) != tt.want {
+				t.Fatalf("got %v\nwant %v", len(events), tt.want)
+			}
+			wantFirst := map[string]any{
+				"id":      "1",
+				"event":   "progress",
+				"data":    map[string]any{"n": float64(1), "done": false},
+				"rawData": "{\"n\": 1,\n\"done\": false}",
+			}
+			if diff := cmp.Diff(events[0], wantFirst); diff != "" {
+				t.Error(diff)
+			}
+			if len(events) < 4 {
+				return
+			}
+			wantLast := map[string]any{
+				"id":      "3",
+				"event":   "message",
+				"data":    "hello",
+				"rawData": "hello",
+				"retry":   1000,
+			}
+			if diff := cmp.Diff(events[3], wantLast); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -104,6 +107,10 @@ func TestRequestBody(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	dummySVG, err := os.ReadFile("testdata/dummy.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	tests := []struct {
 		in        string
@@ -150,6 +157,72 @@ filename: testdata/dummy.png`,
 			MediaTypeApplicationOctetStream,
 			`ABC`,
 		},
+		{
+			`
+op: replace
+value: alice`,
+			MediaTypeApplicationMergePatchJSON,
+			`{"op":"replace","value":"alice"}`,
+		},
+		{
+			`
+data:
+  one: ichi`,
+			"application/vnd.company.v2+json; charset=utf-8",
+			`{"data":{"one":"ichi"}}`,
+		},
+		{
+			`
+users:
+  -count: 2
+  user:
+    -
+      -id: 1
+      name: alice
+    -
+      -id: 2
+      name: bob`,
+			MediaTypeApplicationXML,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<users count="2"><user id="1"><name>alice</name></user><user id="2"><name>bob</name></user></users>`,
+		},
+		{
+			`
+Result:
+  -lang: en
+  '#text': ok`,
+			"application/soap+xml",
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<Result lang="en">ok</Result>`,
+		},
+		{
+			`<users count="0"/>`,
+			MediaTypeTextXML,
+			`<users count="0"/>`,
+		},
+		{
+			`
+data:
+  one: ichi`,
+			MediaTypeApplicationYAML,
+			"data:\n  one: ichi\n",
+		},
+		{
+			`
+- name: alice
+- name: bob`,
+			MediaTypeApplicationNDJSON,
+			"{\"name\":\"alice\"}\n{\"name\":\"bob\"}\n",
+		},
+		{
+			`
+filename: testdata/dummy.svg`,
+			"image/svg+xml",
+			string(dummySVG),
+		},
+		{
+			`<h1>Hello</h1>`,
+			"text/html",
+			`<h1>Hello</h1>`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -497,6 +570,89 @@ func TestHTTPRunnerWithHandler(t *testing.T) {
 	}
 }
 
+func TestHandlerTransportClosesBody(t *testing.T) {
+	tr := &handlerTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	body := &closeRecorder{Reader: strings.NewReader(`{"key":"value"}`)}
+	req, err := http.NewRequest(http.MethodPost, "http://runn.test/users", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if !body.closed {
+		t.Error("request body is not closed")
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestHTTPRunnerWithHandlerParity(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/v1/me", http.StatusFound)
+	})
+	mux.HandleFunc("/v1/me", func(w http.ResponseWriter, r *http.Request) {
+		var session string
+		if c, err := r.Cookie("session"); err == nil {
+			session = c.Value
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"host":"%s","session":"%s"}`, r.Host, session)))
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunnerWithHandler("req", mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.endpoint, err = url.Parse("http://api.example.com/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useCookie := true
+	reqs := []*httpRequest{
+		{path: "/login", method: http.MethodGet, headers: map[string]string{}},
+		{path: "/old", method: http.MethodGet, headers: map[string]string{}, useCookie: &useCookie},
+	}
+	for _, req := range reqs {
+		if err := r.Run(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res := o.store.latest()["res"].(map[string]any)
+	if got := res["status"]; got != http.StatusOK {
+		t.Errorf("got %v\nwant %v", got, http.StatusOK)
+	}
+	want := map[string]any{"host": "api.example.com", "session": "abc"}
+	if diff := cmp.Diff(res["body"], want); diff != "" {
+		t.Error(diff)
+	}
+	if got := len(res["redirects"].([]any)); got != 1 {
+		t.Errorf("got %v\nwant %v", got, 1)
+	}
+}
+
 func TestNotFollowRedirect(t *testing.T) {
 	tests := []struct {
 		req               *httpRequest
@@ -557,6 +713,160 @@ func TestNotFollowRedirect(t *testing.T) {
 	}
 }
 
+func TestHTTPRunnerRedirects(t *testing.T) {
+	follow := true
+	notFollow := false
+	zero := 0
+	one := 1
+	two := 2
+	tests := []struct {
+		name              string
+		notFollowRedirect bool
+		followRedirect    *bool
+		maxRedirects      *int
+		wantStatus        int
+		wantURLs          []string
+		wantErr           bool
+	}{
+		{"default", false, nil, nil, http.StatusOK, []string{"/a", "/b"}, false},
+		{"notFollowRedirect", true, nil, nil, http.StatusFound, []string{}, false},
+		{"override notFollowRedirect", true, &follow, nil, http.StatusOK, []string{"/a", "/b"}, false},
+		{"followRedirect: false", false, &notFollow, nil, http.StatusFound, []string{}, false},
+		{"maxRedirects: 2", false, nil, &two, http.StatusOK, []string{"/a", "/b"}, false},
+		{"maxRedirects: 1", false, nil, &one, http.StatusMovedPermanently, []string{"/a"}, false},
+		{"maxRedirects: 0", false, nil, &zero, http.StatusFound, []string{}, false},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "a"})
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ctx := context.Background()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			if tt.notFollowRedirect {
+				r.client.CheckRedirect = notFollowRedirectFn
+			}
+			req := &httpRequest{
+				path:           "/a",
+				method:         http.MethodGet,
+				headers:        map[string]string{},
+				followRedirect: tt.followRedirect,
+				maxRedirects:   tt.maxRedirects,
+			}
+			if err := r.Run(ctx, req); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("want error")
+			}
+			res := o.store.latest()["res"].(map[string]any)
+			if got := res["status"]; got != tt.wantStatus {
+				t.Errorf("got %v\nwant %v", got, tt.wantStatus)
+			}
+			redirects := res["redirects"].([]any)
+			got := []string{}
+			for _, rd := range redirects {
+				got = append(got, strings.TrimPrefix(rd.(map[string]any)["url"].(string), ts.URL))
+			}
+			if diff := cmp.Diff(got, tt.wantURLs); diff != "" {
+				t.Error(diff)
+			}
+			if len(redirects) > 0 {
+				first := redirects[0].(map[string]any)
+				if got := first["status"]; got != http.StatusFound {
+					t.Errorf("got %v\nwant %v", got, http.StatusFound)
+				}
+				if got := first["headers"].(http.Header).Get("Location"); got != "/b" {
+					t.Errorf("got %v\nwant %v", got, "/b")
+				}
+				c, ok := first["cookies"].(map[string]*http.Cookie)["session"]
+				if !ok || c.Value != "a" {
+					t.Errorf("got %v", first["cookies"])
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerDefaultMaxRedirects(t *testing.T) {
+	tests := []struct {
+		redirects int
+		wantErr   bool
+	}{
+		{defaultMaxRedirects - 1, false},
+		{defaultMaxRedirects, true},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if n == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d redirects", tt.redirects), func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			req := &httpRequest{
+				path:    fmt.Sprintf("/redirect/%d", tt.redirects),
+				method:  http.MethodGet,
+				headers: map[string]string{},
+			}
+			if err := r.Run(ctx, req); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
 func TestHTTPCerts(t *testing.T) {
 	tests := []struct {
 		setCacert       bool
@@ -665,118 +975,143 @@ func TestSetCookieHeader(t *testing.T) {
 
 	tests := []struct {
 		useCookie *bool
-		path      string
-		cookies   map[string]map[string]*http.Cookie
+		setURL    string
+		cookies   []*http.Cookie
+		url       string
 		want      string
 	}{
 		{
 			&use,
-			"",
-			map[string]map[string]*http.Cookie{},
+			"http://example.com/",
+			[]*http.Cookie{},
+			"http://example.com/",
 			"",
 		},
 		{
 			&use,
-			"",
-			map[string]map[string]*http.Cookie{"": {"key": &http.Cookie{Name: "key", Value: "value1"}}},
+			"http://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value1"}},
+			"http://example.com/",
 			"key=value1",
 		},
 		{
 			&notUse,
-			"",
-			map[string]map[string]*http.Cookie{"": {"key": &http.Cookie{Name: "key", Value: "value2"}}},
+			"http://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value2"}},
+			"http://example.com/",
 			"",
 		},
 		{
 			nil,
-			"",
-			map[string]map[string]*http.Cookie{"": {"key": &http.Cookie{Name: "key", Value: "value2"}}},
+			"http://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value2"}},
+			"http://example.com/",
 			"",
 		},
 		{
 			&use,
-			"/users",
-			map[string]map[string]*http.Cookie{"": {"key": &http.Cookie{Name: "key", Value: "value3", Path: "/users"}}},
+			"http://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value3", Path: "/users"}},
+			"http://example.com/users",
 			"key=value3",
 		},
 		{
 			&use,
-			"/users/k1LoW",
-			map[string]map[string]*http.Cookie{"": {"key": &http.Cookie{Name: "key", Value: "value4", Path: "/users"}}},
+			"http://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value4", Path: "/users"}},
+			"http://example.com/users/k1LoW",
 			"key=value4",
 		},
 		{
 			&use,
-			"/users/k1LoW",
-			map[string]map[string]*http.Cookie{"": {"key": &http.Cookie{Name: "key", Value: "value5", Path: "/userz"}}},
+			"http://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value5", Path: "/userz"}},
+			"http://example.com/users/k1LoW",
 			"",
 		},
 		{
 			&use,
+			"https://gitlab.com/",
+			[]*http.Cookie{{Name: "key", Value: "value6", Path: "/users"}},
 			"https://github.com/users/k1LoW",
-			map[string]map[string]*http.Cookie{"gitlab.com": {"key": &http.Cookie{Name: "key", Value: "value6", Path: "/users"}}},
 			"",
 		},
 		{
 			&use,
+			"https://github.com/",
+			[]*http.Cookie{{Name: "key", Value: "value7", Path: "/users"}},
 			"https://github.com/users/k1LoW",
-			map[string]map[string]*http.Cookie{"github.com": {"key": &http.Cookie{Name: "key", Value: "value7", Path: "/users"}}},
 			"key=value7",
 		},
 		{
 			&use,
+			"https://github.com/",
+			[]*http.Cookie{{Name: "key", Value: "value8", Path: "/", Domain: "github.com"}},
 			"https://gist.github.com/k1low",
-			map[string]map[string]*http.Cookie{"github.com": {"key": &http.Cookie{Name: "key", Value: "value8", Path: "/"}}},
 			"key=value8",
 		},
 		{
 			&use,
+			"https://github.com/",
+			[]*http.Cookie{{Name: "key", Value: "value9", Path: "/"}},
 			"https://gist.github.com/k1low",
-			map[string]map[string]*http.Cookie{"github.com": {"key": &http.Cookie{Name: "key", Value: "value9", Path: "/", Expires: time.Now()}}},
 			"",
 		},
 		{
 			&use,
+			"https://github.com/",
+			[]*http.Cookie{{Name: "key", Value: "value10", Path: "/", Domain: "github.com", Expires: time.Now()}},
+			"https://gist.github.com/k1low",
+			"",
+		},
+		{
+			&use,
+			"http://localhost/",
+			[]*http.Cookie{{Name: "key", Value: "value11", Path: "/"}},
 			"https://127.0.0.1/k1low",
-			map[string]map[string]*http.Cookie{"localhost": {"key": &http.Cookie{Name: "key", Value: "value10", Path: "/"}}},
-			"key=value10",
+			"",
 		},
 		{
 			&use,
+			"http://localhost:8080/",
+			[]*http.Cookie{{Name: "key", Value: "value12", Path: "/"}},
 			"https://localhost/k1low",
-			map[string]map[string]*http.Cookie{"localhost": {"key": &http.Cookie{Name: "key", Value: "value11", Path: "/"}}},
-			"key=value11",
+			"key=value12",
 		},
 		{
 			&use,
-			"https://localhost:8080/k1low",
-			map[string]map[string]*http.Cookie{"localhost:8080": {"key": &http.Cookie{Name: "key", Value: "value12", Path: "/"}}},
-			"key=value12",
+			"https://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value13", Secure: true}},
+			"http://example.com/",
+			"",
 		},
 		{
 			&use,
-			"https://localhost:8080/k1low",
-			map[string]map[string]*http.Cookie{"localhost": {"key": &http.Cookie{Name: "key", Value: "value13", Path: "/"}}},
-			"key=value13",
+			"https://example.com/",
+			[]*http.Cookie{{Name: "key", Value: "value14", Path: "/"}, {Name: "key", Value: "value15", Path: "/users"}},
+			"https://example.com/users/k1LoW",
+			"key=value15; key=value14",
 		},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.path, func(t *testing.T) {
+		t.Run(tt.url, func(t *testing.T) {
+			jar := newCookieJar()
+			jar.SetCookies(pathToURL(t, tt.setURL), tt.cookies)
 			r := &httpRequest{
-				path:      tt.path,
+				path:      tt.url,
 				method:    http.MethodGet,
 				mediaType: MediaTypeApplicationJSON,
 				useCookie: tt.useCookie,
 			}
 			req := &http.Request{
 				Method: http.MethodPost,
-				URL:    pathToURL(t, tt.path),
+				URL:    pathToURL(t, tt.url),
 				Header: http.Header{"Content-Type": []string{"application/json"}},
 				Body:   io.NopCloser(strings.NewReader(`{"username": "alice", "password": "passw0rd"}`)),
 			}
 
-			r.setCookieHeader(req, tt.cookies)
+			r.setCookieHeader(req, jar)
 			got := req.Header.Get("Cookie")
 
 			if got != tt.want {
@@ -785,3 +1120,477 @@ func TestSetCookieHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPRunnerRetry(t *testing.T) {
+	retry := &httpRetryConfig{Count: 3, MinInterval: "1ms", MaxInterval: "10ms"}
+	tests := []struct {
+		name       string
+		method     string
+		headers    map[string]string
+		failures   int
+		retry      *httpRetryConfig
+		wantCalled int
+		wantErr    bool
+	}{
+		{"no failures", http.MethodGet, nil, 0, nil, 1, false},
+		{"no retry", http.MethodGet, nil, 1, nil, 1, true},
+		{"retry", http.MethodGet, nil, 2, retry, 3, false},
+		{"retry count exceeded", http.MethodGet, nil, 3, &httpRetryConfig{Count: 2, MinInterval: "1ms", MaxInterval: "10ms"}, 3, true},
+		{"PUT is retried", http.MethodPut, nil, 2, retry, 3, false},
+		{"POST is not retried", http.MethodPost, nil, 2, retry, 1, true},
+		{"POST with Idempotency-Key is retried", http.MethodPost, map[string]string{"Idempotency-Key": "8e03978e"}, 2, retry, 3, false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var called int
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called++
+				if called <= tt.failures {
+					// Close connection without response
+					hj, ok := w.(http.Hijacker)
+					if !ok {
+						t.Fatal("could not hijack")
+					}
+					conn, _, err := hj.Hijack()
+					if err != nil {
+						t.Fatal(err)
+					}
+					_ = conn.Close()
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(hs.Close)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			if err := r.setConnectionOptions(&httpRunnerConfig{Retry: tt.retry, DisableKeepAlives: true}); err != nil {
+				t.Fatal(err)
+			}
+			headers := map[string]string{}
+			for k, v := range tt.headers {
+				headers[k] = v
+			}
+			req := &httpRequest{
+				path:    "/",
+				method:  tt.method,
+				headers: headers,
+			}
+			if tt.method != http.MethodGet {
+				req.mediaType = MediaTypeApplicationJSON
+				req.body = map[string]any{"key": "value"}
+			}
+			err = r.Run(ctx, req)
+			if called != tt.wantCalled {
+				t.Errorf("got %v\nwant %v", called, tt.wantCalled)
+			}
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerRetryTimeout(t *testing.T) {
+	ctx := context.Background()
+	var called int
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(hs.Close)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunner("req", hs.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	if err := r.setConnectionOptions(&httpRunnerConfig{Retry: &httpRetryConfig{Count: 3, MinInterval: "1ms", MaxInterval: "10ms"}}); err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{
+		path:    "/",
+		method:  http.MethodGet,
+		headers: map[string]string{},
+		timeout: 100 * time.Millisecond,
+	}
+	if err := r.Run(ctx, req); err == nil {
+		t.Error("want error")
+	}
+	if want := 1; called != want {
+		t.Errorf("got %v\nwant %v", called, want)
+	}
+}
+
+func TestHTTPRequestTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		wantErr bool
+	}{
+		{0, false},
+		{100 * time.Millisecond, true},
+		{5 * time.Second, false},
+	}
+	ctx := context.Background()
+	hs := testutil.HTTPServer(t)
+	for _, tt := range tests {
+		t.Run(tt.timeout.String(), func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			r.client.Timeout = 2 * time.Second
+			req := &httpRequest{
+				path:    "/sleep/1",
+				method:  http.MethodGet,
+				headers: map[string]string{},
+				timeout: tt.timeout,
+			}
+			if err := r.Run(ctx, req); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerQuery(t *testing.T) {
+	tests := []struct {
+		path  string
+		query url.Values
+		want  url.Values
+	}{
+		{"/users", nil, url.Values{}},
+		{"/users?page=2", nil, url.Values{"page": []string{"2"}}},
+		{
+			"/users",
+			url.Values{"q": []string{"a&b=c"}, "name": []string{"日本語"}},
+			url.Values{"q": []string{"a&b=c"}, "name": []string{"日本語"}},
+		},
+		{
+			"/users?page=2",
+			url.Values{"tag": []string{"go", "grpc"}},
+			url.Values{"page": []string{"2"}, "tag": []string{"go", "grpc"}},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		for _, useHandler := range []bool{false, true} {
+			tt := tt
+			useHandler := useHandler
+			t.Run(fmt.Sprintf("%s %v (handler: %v)", tt.path, tt.query, useHandler), func(t *testing.T) {
+				var got url.Values
+				h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					got = r.URL.Query()
+					w.WriteHeader(http.StatusOK)
+				})
+				o, err := New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				var r *httpRunner
+				if useHandler {
+					r, err = newHTTPRunnerWithHandler("req", h)
+				} else {
+					ts := httptest.NewServer(h)
+					t.Cleanup(func() {
+						ts.Close()
+					})
+					r, err = newHTTPRunner("req", ts.URL)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.operator = o
+				req := &httpRequest{
+					path:    tt.path,
+					method:  http.MethodGet,
+					headers: map[string]string{},
+					query:   tt.query,
+				}
+				if err := r.Run(ctx, req); err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(got, tt.want); diff != "" {
+					t.Error(diff)
+				}
+			})
+		}
+	}
+}
+
+func TestHTTPRunnerUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"host":"%s","page":"%s"}`, r.Host, r.URL.Query().Get("page"))))
+	})
+	ts := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = ts.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = ts.Close()
+	})
+
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunner("req", fmt.Sprintf("http+unix://%s", sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	req := &httpRequest{
+		path:    "/api/users?page=2",
+		method:  http.MethodGet,
+		headers: map[string]string{},
+	}
+	if err := r.Run(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	got := o.store.latest()["res"].(map[string]any)["body"]
+	want := map[string]any{"host": "localhost", "page": "2"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseHTTPEndpoint(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string
+		wantSock string
+		wantErr  bool
+	}{
+		{"https://example.com/api", "https://example.com/api", "", false},
+		{"http+unix:///var/run/app.sock", "http://localhost", "/var/run/app.sock", false},
+		{"http+unix://var/run/app.sock", "", "", true},
+		{"http+unix://", "", "", true},
+	}
+	for _, tt := range tests {
+		got, sock, err := parseHTTPEndpoint(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: %v", tt.in, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("%s: want error", tt.in)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+		if sock != tt.wantSock {
+			t.Errorf("got %v\nwant %v", sock, tt.wantSock)
+		}
+	}
+}
+
+func TestHTTPRunnerProxy(t *testing.T) {
+	tests := []struct {
+		name        string
+		user        string
+		proxyUser   string
+		noProxy     string
+		wantProxied bool
+		wantErr     bool
+	}{
+		{"via proxy", "", "", "", true, false},
+		{"via proxy with auth", "alice", "alice:pass@", "", true, false},
+		{"invalid auth", "alice", "bob:pass@", "", false, true},
+		{"no proxy", "", "", "127.0.0.1", false, false},
+	}
+	ctx := context.Background()
+	hs := testutil.HTTPServer(t)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ps := testutil.HTTPProxyServer(t, tt.user, "pass")
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			pu := strings.Replace(ps.URL, "http://", "http://"+tt.proxyUser, 1)
+			if err := r.setConnectionOptions(&httpRunnerConfig{Proxy: pu, NoProxy: tt.noProxy}); err != nil {
+				t.Fatal(err)
+			}
+			req := &httpRequest{
+				path:    "/users/1",
+				method:  http.MethodGet,
+				headers: map[string]string{},
+			}
+			if err := r.Run(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			got := o.store.latest()["res"].(map[string]any)["status"]
+			if tt.wantErr {
+				if want := http.StatusProxyAuthRequired; got != want {
+					t.Errorf("got %v\nwant %v", got, want)
+				}
+				return
+			}
+			if want := http.StatusOK; got != want {
+				t.Errorf("got %v\nwant %v", got, want)
+			}
+			if proxied := len(ps.Requests()) > 0; proxied != tt.wantProxied {
+				t.Errorf("got %v\nwant %v", proxied, tt.wantProxied)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerTimings(t *testing.T) {
+	ctx := context.Background()
+	hs, hr := testutil.HTTPServerAndRouter(t)
+	tests := []struct {
+		name   string
+		runner func() (*httpRunner, error)
+	}{
+		{"client", func() (*httpRunner, error) { return newHTTPRunner("req", hs.URL) }},
+		{"handler", func() (*httpRunner, error) { return newHTTPRunnerWithHandler("req", hr) }},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := tt.runner()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			req := &httpRequest{
+				path:    "/sleep/1",
+				method:  http.MethodGet,
+				headers: map[string]string{},
+			}
+			if err := r.Run(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			timings, ok := res["timings"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid timings: %#v", res["timings"])
+			}
+			for _, k := range []string{"dns", "connect", "tls", "ttfb", "transfer", "total"} {
+				if _, ok := timings[k].(float64); !ok {
+					t.Errorf("invalid timings.%s: %#v", k, timings[k])
+				}
+			}
+			if got := timings["ttfb"].(float64); got < 1000 {
+				t.Errorf("got %v\nwant >= 1000", got)
+			}
+			if timings["total"].(float64) < timings["ttfb"].(float64) {
+				t.Errorf("total should be greater than or equal to ttfb: %#v", timings)
+			}
+			if tt.name == "client" && timings["connect"].(float64) <= 0 {
+				t.Errorf("connect should be recorded: %#v", timings)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerMalformedResponseBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{"application/json", `{"data":`, true},
+		{"application/xml", `<users><user>`, false},
+		{"application/x-www-form-urlencoded", `a=%zz`, false},
+		{"text/csv", "id,name\n1,\"alice\n", false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.contentType, func(t *testing.T) {
+			hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(hs.Close)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", hs.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			req := &httpRequest{
+				path:    "/",
+				method:  http.MethodGet,
+				headers: map[string]string{},
+			}
+			if err := r.Run(ctx, req); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("want error")
+			}
+			res, ok := o.store.latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.latest()["res"])
+			}
+			if res["body"] != nil {
+				t.Errorf("got %#v\nwant nil", res["body"])
+			}
+			if got := res["rawBody"]; got != tt.body {
+				t.Errorf("got %#v\nwant %#v", got, tt.body)
+			}
+		})
+	}
+}
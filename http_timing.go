@@ -0,0 +1,156 @@
+package runn
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+const (
+	httpTimingDNS      = "dns"
+	httpTimingConnect  = "connect"
+	httpTimingTLS      = "tls"
+	httpTimingTTFB     = "ttfb"
+	httpTimingTransfer = "transfer"
+	httpTimingTotal    = "total"
+)
+
+// HTTPTimings is the timing breakdown of the HTTP request.
+type HTTPTimings struct {
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration
+	Transfer time.Duration
+	Total    time.Duration
+}
+
+// httpTimer records the time of each phase of the HTTP request using httptrace.
+type httpTimer struct {
+	startedAt         time.Time
+	dnsStart          time.Time
+	dnsDone           time.Time
+	connectStart      time.Time
+	connectDone       time.Time
+	tlsHandshakeStart time.Time
+	tlsHandshakeDone  time.Time
+	firstByte         time.Time
+	doneAt            time.Time
+	mu                sync.Mutex
+}
+
+type httpTimingPhase struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+func newHTTPTimer() *httpTimer {
+	return &httpTimer{}
+}
+
+func (t *httpTimer) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.startedAt = time.Now()
+}
+
+func (t *httpTimer) gotFirstResponseByte() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.firstByte = time.Now()
+}
+
+func (t *httpTimer) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.doneAt = time.Now()
+	if t.firstByte.IsZero() {
+		t.firstByte = t.doneAt
+	}
+}
+
+func (t *httpTimer) clientTrace() *httptrace.ClientTrace {
+	set := func(v *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*v = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			set(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			set(&t.dnsDone)
+		},
+		ConnectStart: func(_, _ string) {
+			set(&t.connectStart)
+		},
+		ConnectDone: func(_, _ string, _ error) {
+			set(&t.connectDone)
+		},
+		TLSHandshakeStart: func() {
+			set(&t.tlsHandshakeStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			set(&t.tlsHandshakeDone)
+		},
+		GotFirstResponseByte: t.gotFirstResponseByte,
+	}
+}
+
+// phases returns the phases that actually happened.
+func (t *httpTimer) phases() []httpTimingPhase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ps []httpTimingPhase
+	for _, p := range []httpTimingPhase{
+		{httpTimingDNS, t.dnsStart, t.dnsDone},
+		{httpTimingConnect, t.connectStart, t.connectDone},
+		{httpTimingTLS, t.tlsHandshakeStart, t.tlsHandshakeDone},
+		{httpTimingTTFB, t.startedAt, t.firstByte},
+		{httpTimingTransfer, t.firstByte, t.doneAt},
+	} {
+		if p.start.IsZero() || p.end.Before(p.start) {
+			continue
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+func (t *httpTimer) timings() *HTTPTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &HTTPTimings{
+		DNS:      between(t.dnsStart, t.dnsDone),
+		Connect:  between(t.connectStart, t.connectDone),
+		TLS:      between(t.tlsHandshakeStart, t.tlsHandshakeDone),
+		TTFB:     between(t.startedAt, t.firstByte),
+		Transfer: between(t.firstByte, t.doneAt),
+		Total:    between(t.startedAt, t.doneAt),
+	}
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// toMap returns timings in milliseconds.
+func (tm *HTTPTimings) toMap() map[string]any {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return map[string]any{
+		httpTimingDNS:      ms(tm.DNS),
+		httpTimingConnect:  ms(tm.Connect),
+		httpTimingTLS:      ms(tm.TLS),
+		httpTimingTTFB:     ms(tm.TTFB),
+		httpTimingTransfer: ms(tm.Transfer),
+		httpTimingTotal:    ms(tm.Total),
+	}
+}
@@ -9,14 +9,16 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	legacyrouter "github.com/getkin/kin-openapi/routers/legacy"
+	"go.uber.org/multierr"
 )
 
-type httpValidator interface { //nostyle:ifacenames
+// HTTPValidator validates HTTP requests sent by HTTP runners and their HTTP responses.
+// The error returned is reported as the error of the step.
+type HTTPValidator interface { //nostyle:ifacenames
 	ValidateRequest(ctx context.Context, req *http.Request) error
 	ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error
 }
@@ -33,8 +35,8 @@ func (e *UnsupportedError) Unwrap() error {
 	return e.Cause
 }
 
-func newHttpValidator(c *httpRunnerConfig) (httpValidator, error) {
-	if c.OpenApi3DocLocation != "" || c.openApi3Doc != nil {
+func newHttpValidator(c *httpRunnerConfig) (HTTPValidator, error) {
+	if c.OpenApi3DocLocation != "" || c.Swagger2DocLocation != "" || c.openApi3Doc != nil {
 		return newOpenApi3Validator(c)
 	}
 	return newNopValidator(), nil
@@ -54,43 +56,130 @@ func newNopValidator() *nopValidator {
 	return &nopValidator{}
 }
 
+// httpValidators is the chain of HTTP validators.
+// All validators are run in order and their errors are joined.
+type httpValidators []HTTPValidator
+
+// chainHTTPValidators chains HTTP validators.
+func chainHTTPValidators(vs ...HTTPValidator) HTTPValidator {
+	var c httpValidators
+	for _, v := range vs {
+		switch vv := v.(type) {
+		case nil, *nopValidator:
+		case httpValidators:
+			c = append(c, vv...)
+		default:
+			c = append(c, v)
+		}
+	}
+	switch len(c) {
+	case 0:
+		return newNopValidator()
+	case 1:
+		return c[0]
+	}
+	return c
+}
+
+func (vs httpValidators) ValidateRequest(ctx context.Context, req *http.Request) error {
+	var b []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		b, err = io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for _, v := range vs {
+		// Each validator can read the request body
+		if b != nil {
+			req.Body = io.NopCloser(bytes.NewReader(b))
+		}
+		if err := v.ValidateRequest(ctx, req); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if b != nil {
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	return multierr.Combine(errs...)
+}
+
+func (vs httpValidators) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
+	var b []byte
+	if res.Body != nil {
+		var err error
+		b, err = io.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+	}
+	var errs, unsupported []error
+	for _, v := range vs {
+		// Each validator can read the response body
+		if b != nil {
+			res.Body = io.NopCloser(bytes.NewReader(b))
+		}
+		if err := v.ValidateResponse(ctx, req, res); err != nil {
+			var target *UnsupportedError
+			if errors.As(err, &target) {
+				unsupported = append(unsupported, err)
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	if b != nil {
+		res.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	if len(errs) > 0 {
+		return multierr.Combine(errs...)
+	}
+	return multierr.Combine(unsupported...)
+}
+
+// openApi3ValidatorFrom returns the OpenAPI validator in the validator ( or the chain of validators ).
+func openApi3ValidatorFrom(v HTTPValidator) (*openApi3Validator, bool) {
+	switch vv := v.(type) {
+	case *openApi3Validator:
+		return vv, true
+	case httpValidators:
+		for _, v := range vv {
+			if ov, ok := openApi3ValidatorFrom(v); ok {
+				return ov, true
+			}
+		}
+	}
+	return nil, false
+}
+
 type openApi3Validator struct {
 	skipValidateRequest  bool
 	skipValidateResponse bool
 	doc                  *openapi3.T
+	docLocation          string
 }
 
 func newOpenApi3Validator(c *httpRunnerConfig) (*openApi3Validator, error) {
-	if c.OpenApi3DocLocation != "" {
-		l := c.OpenApi3DocLocation
-		ctx := context.Background()
-		loader := openapi3.NewLoader()
-		var doc *openapi3.T
-		switch {
-		case strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "http://"):
-			u, err := url.Parse(l)
-			if err != nil {
-				return nil, err
-			}
-			doc, err = loader.LoadFromURI(u)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			b, err := readFile(l)
-			if err != nil {
-				return nil, err
-			}
-			doc, err = loader.LoadFromData(b)
-			if err != nil {
-				return nil, err
-			}
+	if c.OpenApi3DocLocation != "" && c.Swagger2DocLocation != "" {
+		return nil, errors.New("openapi3 and swagger2 cannot be used at the same time")
+	}
+	docLocation := c.OpenApi3DocLocation
+	switch {
+	case c.OpenApi3DocLocation != "":
+		doc, err := loadOpenAPI3Doc(c.OpenApi3DocLocation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", c.OpenApi3DocLocation, err)
 		}
-
-		if err := doc.Validate(ctx); err != nil {
-			return nil, fmt.Errorf("openapi3 document validation error: %w", err)
+		c.openApi3Doc = doc
+	case c.Swagger2DocLocation != "":
+		doc, err := loadSwagger2Doc(c.Swagger2DocLocation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", c.Swagger2DocLocation, err)
 		}
 		c.openApi3Doc = doc
+		docLocation = c.Swagger2DocLocation
 	}
 
 	if c.openApi3Doc == nil {
@@ -100,9 +189,21 @@ func newOpenApi3Validator(c *httpRunnerConfig) (*openApi3Validator, error) {
 		skipValidateRequest:  c.SkipValidateRequest,
 		skipValidateResponse: c.SkipValidateResponse,
 		doc:                  c.openApi3Doc,
+		docLocation:          docLocation,
 	}, nil
 }
 
+// docKey returns the key to identify the OpenAPI document.
+func (v *openApi3Validator) docKey() string {
+	if v.docLocation != "" {
+		return v.docLocation
+	}
+	if v.doc.Info != nil {
+		return fmt.Sprintf("%s %s", v.doc.Info.Title, v.doc.Info.Version)
+	}
+	return ""
+}
+
 // FIXME: better to depend on any library
 // currently refer to https://developer.mozilla.org/en-US/docs/Web/HTTP/Basics_of_HTTP/MIME_types
 var registerBodyMimeTypes = []string{
@@ -2,11 +2,18 @@ package runn
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
 )
 
 const validOpenApi3Spec = `
@@ -230,3 +237,272 @@ func pathToURL(t *testing.T, p string) *url.URL {
 	}
 	return u
 }
+
+type bodyValidator struct {
+	reqBodies []string
+	resBodies []string
+	err       error
+}
+
+func (v *bodyValidator) ValidateRequest(ctx context.Context, req *http.Request) error {
+	if req.Body == nil {
+		v.reqBodies = append(v.reqBodies, "")
+		return v.err
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	v.reqBodies = append(v.reqBodies, string(b))
+	return v.err
+}
+
+func (v *bodyValidator) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	v.resBodies = append(v.resBodies, string(b))
+	return v.err
+}
+
+func TestChainHTTPValidators(t *testing.T) {
+	errA := errors.New("invalid a")
+	errB := errors.New("invalid b")
+	tests := []struct {
+		name     string
+		vs       []*bodyValidator
+		wantErrs []error
+	}{
+		{"no errors", []*bodyValidator{{}, {}}, nil},
+		{"one error", []*bodyValidator{{err: errA}, {}}, []error{errA}},
+		{"joined errors", []*bodyValidator{{err: errA}, {err: errB}}, []error{errA, errB}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vs []HTTPValidator
+			for _, v := range tt.vs {
+				vs = append(vs, v)
+			}
+			c := chainHTTPValidators(append(vs, nil, newNopValidator())...)
+			req := &http.Request{
+				Method: http.MethodPost,
+				URL:    pathToURL(t, "/users"),
+				Body:   io.NopCloser(strings.NewReader(`{"username": "alice"}`)),
+			}
+			res := &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+			}
+			reqErr := c.ValidateRequest(ctx, req)
+			resErr := c.ValidateResponse(ctx, req, res)
+			for _, err := range []error{reqErr, resErr} {
+				if len(tt.wantErrs) == 0 && err != nil {
+					t.Errorf("got %v\nwant no error", err)
+				}
+				for _, want := range tt.wantErrs {
+					if !errors.Is(err, want) {
+						t.Errorf("got %v\nwant %v", err, want)
+					}
+				}
+			}
+			for _, v := range tt.vs {
+				if diff := cmp.Diff(v.reqBodies, []string{`{"username": "alice"}`}); diff != "" {
+					t.Error(diff)
+				}
+				if diff := cmp.Diff(v.resBodies, []string{`{"id": 1}`}); diff != "" {
+					t.Error(diff)
+				}
+			}
+			// Bodies are still readable after validation
+			b, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != `{"id": 1}` {
+				t.Errorf("got %v\nwant %v", got, `{"id": 1}`)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerValidatorOptions(t *testing.T) {
+	hs := testutil.HTTPServer(t)
+	errInvalid := errors.New("invalid by custom validator")
+	tests := []struct {
+		name       string
+		validators string
+		opts       func(named, appended *bodyValidator) []Option
+		wantNewErr bool
+		wantErr    bool
+		wantCalled [2]int
+	}{
+		{
+			"named validator",
+			"[policy]",
+			func(named, appended *bodyValidator) []Option {
+				return []Option{NamedHTTPValidator("policy", named)}
+			},
+			false,
+			false,
+			[2]int{1, 0},
+		},
+		{
+			"named validator and appended validator",
+			"[policy]",
+			func(named, appended *bodyValidator) []Option {
+				appended.err = errInvalid
+				return []Option{NamedHTTPValidator("policy", named), HTTPRunnerValidator("req", appended)}
+			},
+			false,
+			true,
+			[2]int{1, 1},
+		},
+		{
+			"appended validator for other runner",
+			"[]",
+			func(named, appended *bodyValidator) []Option {
+				appended.err = errInvalid
+				return []Option{HTTPRunnerValidator("other", appended)}
+			},
+			false,
+			false,
+			[2]int{0, 0},
+		},
+		{
+			"validator not found",
+			"[policy]",
+			func(named, appended *bodyValidator) []Option {
+				return nil
+			},
+			true,
+			false,
+			[2]int{0, 0},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "book.yml")
+			rb := fmt.Sprintf(`desc: Custom validators
+runners:
+  req:
+    endpoint: %s
+    validators: %s
+steps:
+  -
+    req:
+      /users:
+        get:
+          body: null
+    test: current.res.status == 200
+`, hs.URL, tt.validators)
+			if err := os.WriteFile(p, []byte(rb), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			named := &bodyValidator{}
+			appended := &bodyValidator{}
+			opts := append([]Option{Book(p)}, tt.opts(named, appended)...)
+			o, err := New(opts...)
+			if err != nil {
+				if !tt.wantNewErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantNewErr {
+				t.Fatal("want error")
+			}
+			err = o.Run(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errInvalid) {
+				t.Errorf("got %v\nwant %v", err, errInvalid)
+			}
+			if got := [2]int{len(named.reqBodies), len(appended.reqBodies)}; got != tt.wantCalled {
+				t.Errorf("got %v\nwant %v", got, tt.wantCalled)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerValidatorOptionsInIncludedRunbook(t *testing.T) {
+	hs := testutil.HTTPServer(t)
+	tests := []struct {
+		name         string
+		childRunners string
+		wantNamed    int
+		wantAppended int
+	}{
+		{
+			"child runbook defines the runner",
+			fmt.Sprintf(`runners:
+  req:
+    endpoint: %s
+    validators: [policy]
+`, hs.URL),
+			2,
+			2,
+		},
+		{
+			"child runbook uses the runner of the parent runbook",
+			"",
+			2,
+			2,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			child := fmt.Sprintf(`desc: Child
+%ssteps:
+  -
+    req:
+      /users:
+        get:
+          body: null
+    test: current.res.status == 200
+`, tt.childRunners)
+			if err := os.WriteFile(filepath.Join(dir, "child.yml"), []byte(child), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			parent := fmt.Sprintf(`desc: Parent
+runners:
+  req:
+    endpoint: %s
+    validators: [policy]
+steps:
+  -
+    req:
+      /users:
+        get:
+          body: null
+    test: current.res.status == 200
+  -
+    include: child.yml
+`, hs.URL)
+			p := filepath.Join(dir, "parent.yml")
+			if err := os.WriteFile(p, []byte(parent), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			named := &bodyValidator{}
+			appended := &bodyValidator{}
+			o, err := New(Book(p), NamedHTTPValidator("policy", named), HTTPRunnerValidator("req", appended))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(named.reqBodies); got != tt.wantNamed {
+				t.Errorf("got %v\nwant %v", got, tt.wantNamed)
+			}
+			if got := len(appended.reqBodies); got != tt.wantAppended {
+				t.Errorf("got %v\nwant %v", got, tt.wantAppended)
+			}
+		})
+	}
+}
@@ -117,6 +117,9 @@ func (rnr *includeRunner) Run(ctx context.Context, c *includeConfig) error {
 	for _, r := range oo.grpcRunners {
 		r.operator = rnr.operator
 	}
+	for _, r := range oo.wsRunners {
+		r.operator = rnr.operator
+	}
 	for _, r := range oo.sshRunners {
 		r.operator = rnr.operator
 	}
@@ -139,6 +142,9 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	for k, r := range o.grpcRunners {
 		popts = append(popts, runnGrpcRunner(k, r))
 	}
+	for k, r := range o.wsRunners {
+		popts = append(popts, runnWSRunner(k, r))
+	}
 	for k, r := range o.sshRunners {
 		popts = append(popts, runnSSHRunner(k, r))
 	}
@@ -147,9 +153,19 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	popts = append(popts, Profile(o.profile))
 	popts = append(popts, SkipTest(o.skipTest))
 	popts = append(popts, Force(o.force))
+	popts = append(popts, runnCookieJar(o.store.cookieJar))
+	popts = append(popts, runnOpenAPICoverage(o.openAPICoverage))
 	for k, f := range o.store.funcs {
 		popts = append(popts, Func(k, f))
 	}
+	for k, v := range o.httpValidators {
+		popts = append(popts, NamedHTTPValidator(k, v))
+	}
+	for k, vs := range o.httpRunnerValidators {
+		for _, v := range vs {
+			popts = append(popts, HTTPRunnerValidator(k, v))
+		}
+	}
 	// Prefer child runbook opts
 	opts = append(popts, opts...)
 	oo, err := New(opts...)
@@ -163,5 +179,6 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	oo.capturers = o.capturers
 	oo.parent = parent
 	oo.store.parentVars = o.store.toMap()
+	oo.registerOpenAPICoverage()
 	return oo, nil
 }
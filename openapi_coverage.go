@@ -0,0 +1,352 @@
+package runn
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/goccy/go-json"
+	"github.com/olekukonko/tablewriter"
+)
+
+// openAPICoverage records operations, response status codes and content types of OpenAPI documents exercised by HTTP runners.
+type openAPICoverage struct {
+	docs map[string]*openAPIDocCoverage
+	keys []string
+	mu   sync.Mutex
+}
+
+type openAPIDocCoverage struct {
+	key  string
+	doc  *openapi3.T
+	hits map[string]int
+}
+
+// OpenAPICoverage is the coverage of the OpenAPI document.
+type OpenAPICoverage struct {
+	Doc               string                      `json:"doc"`
+	Title             string                      `json:"title"`
+	Version           string                      `json:"version"`
+	OperationsCovered int                         `json:"operations_covered"`
+	OperationsTotal   int                         `json:"operations_total"`
+	ResponsesCovered  int                         `json:"responses_covered"`
+	ResponsesTotal    int                         `json:"responses_total"`
+	Operations        []*OpenAPIOperationCoverage `json:"operations"`
+}
+
+// OpenAPIOperationCoverage is the coverage of the operation.
+type OpenAPIOperationCoverage struct {
+	Method      string                     `json:"method"`
+	Path        string                     `json:"path"`
+	OperationID string                     `json:"operation_id,omitempty"`
+	Count       int                        `json:"count"`
+	Responses   []*OpenAPIResponseCoverage `json:"responses"`
+}
+
+// OpenAPIResponseCoverage is the coverage of the response status code and content type of the operation.
+type OpenAPIResponseCoverage struct {
+	Status      string `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Count       int    `json:"count"`
+}
+
+// OpenAPICoverages is the coverage of OpenAPI documents used by HTTP runners.
+type OpenAPICoverages []*OpenAPICoverage
+
+func newOpenAPICoverage() *openAPICoverage {
+	return &openAPICoverage{
+		docs: map[string]*openAPIDocCoverage{},
+	}
+}
+
+// register registers the OpenAPI document of the validator to be reported even if no request is sent.
+func (c *openAPICoverage) register(v HTTPValidator) *openAPIDocCoverage {
+	if c == nil {
+		return nil
+	}
+	ov, ok := openApi3ValidatorFrom(v)
+	if !ok {
+		return nil
+	}
+	key := ov.docKey()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if dc, ok := c.docs[key]; ok {
+		return dc
+	}
+	dc := &openAPIDocCoverage{
+		key:  key,
+		doc:  ov.doc,
+		hits: map[string]int{},
+	}
+	c.docs[key] = dc
+	c.keys = append(c.keys, key)
+	return dc
+}
+
+// registerOpenAPICoverage registers OpenAPI documents of HTTP runners to the coverage.
+func (o *operator) registerOpenAPICoverage() {
+	for _, r := range o.httpRunners {
+		o.openAPICoverage.register(r.validator)
+	}
+}
+
+// record records the operation and the response exercised by the request.
+func (c *openAPICoverage) record(v HTTPValidator, req *http.Request, res *http.Response) {
+	if c == nil {
+		return
+	}
+	dc := c.register(v)
+	if dc == nil {
+		return
+	}
+	ov, _ := openApi3ValidatorFrom(v)
+	input, err := ov.requestInput(req)
+	if err != nil {
+		// The request does not match any operation
+		return
+	}
+	route := input.Route
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	opKey := openAPIOperationKey(route.Method, route.Path)
+	dc.hits[opKey]++
+	if route.Operation == nil || route.Operation.Responses == nil {
+		return
+	}
+	status, ok := openAPIResponseStatus(route.Operation.Responses, res.StatusCode)
+	if !ok {
+		return
+	}
+	rr := route.Operation.Responses[status]
+	var contentType string
+	if rr.Value != nil && len(rr.Value.Content) > 0 {
+		contentType, ok = openAPIContentType(rr.Value.Content, res.Header.Get("Content-Type"))
+		if !ok {
+			return
+		}
+	}
+	dc.hits[openAPIResponseKey(opKey, status, contentType)]++
+}
+
+// result returns the coverage of each OpenAPI document in order of registration.
+func (c *openAPICoverage) result() OpenAPICoverages {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	covs := OpenAPICoverages{}
+	for _, k := range c.keys {
+		covs = append(covs, c.docs[k].result())
+	}
+	return covs
+}
+
+func (dc *openAPIDocCoverage) result() *OpenAPICoverage {
+	cov := &OpenAPICoverage{
+		Doc:        dc.key,
+		Operations: []*OpenAPIOperationCoverage{},
+	}
+	if dc.doc.Info != nil {
+		cov.Title = dc.doc.Info.Title
+		cov.Version = dc.doc.Info.Version
+	}
+	var paths []string
+	for p := range dc.doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		ops := dc.doc.Paths[p].Operations()
+		var methods []string
+		for m := range ops {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		for _, m := range methods {
+			op := ops[m]
+			opKey := openAPIOperationKey(m, p)
+			oc := &OpenAPIOperationCoverage{
+				Method:      m,
+				Path:        p,
+				OperationID: op.OperationID,
+				Count:       dc.hits[opKey],
+				Responses:   []*OpenAPIResponseCoverage{},
+			}
+			if oc.Count > 0 {
+				cov.OperationsCovered++
+			}
+			var statuses []string
+			for s := range op.Responses {
+				statuses = append(statuses, s)
+			}
+			sort.Strings(statuses)
+			for _, s := range statuses {
+				rr := op.Responses[s]
+				if rr.Value == nil || len(rr.Value.Content) == 0 {
+					oc.Responses = append(oc.Responses, &OpenAPIResponseCoverage{
+						Status: s,
+						Count:  dc.hits[openAPIResponseKey(opKey, s, "")],
+					})
+				} else {
+					var cts []string
+					for ct := range rr.Value.Content {
+						cts = append(cts, ct)
+					}
+					sort.Strings(cts)
+					for _, ct := range cts {
+						oc.Responses = append(oc.Responses, &OpenAPIResponseCoverage{
+							Status:      s,
+							ContentType: ct,
+							Count:       dc.hits[openAPIResponseKey(opKey, s, ct)],
+						})
+					}
+				}
+			}
+			for _, rc := range oc.Responses {
+				if rc.Count > 0 {
+					cov.ResponsesCovered++
+				}
+			}
+			cov.OperationsTotal++
+			cov.ResponsesTotal += len(oc.Responses)
+			cov.Operations = append(cov.Operations, oc)
+		}
+	}
+	return cov
+}
+
+// OperationsPercent returns the percentage of covered operations.
+func (cov *OpenAPICoverage) OperationsPercent() float64 {
+	return coveragePercent(cov.OperationsCovered, cov.OperationsTotal)
+}
+
+// ResponsesPercent returns the percentage of covered responses ( status code and content type ).
+func (cov *OpenAPICoverage) ResponsesPercent() float64 {
+	return coveragePercent(cov.ResponsesCovered, cov.ResponsesTotal)
+}
+
+// Out outputs the summary of coverage and untested operations and responses.
+func (covs OpenAPICoverages) Out(out io.Writer) error {
+	for _, cov := range covs {
+		_, _ = fmt.Fprintln(out, "")
+		title := cov.Doc
+		if cov.Title != "" {
+			title = fmt.Sprintf("%s %s (%s)", cov.Title, cov.Version, cov.Doc)
+		}
+		_, _ = fmt.Fprintf(out, "OpenAPI coverage: %s\n", title)
+		_, _ = fmt.Fprintf(out, "  Operations: %d/%d (%.1f%%)\n", cov.OperationsCovered, cov.OperationsTotal, cov.OperationsPercent())
+		_, _ = fmt.Fprintf(out, "  Responses:  %d/%d (%.1f%%)\n", cov.ResponsesCovered, cov.ResponsesTotal, cov.ResponsesPercent())
+		var d [][]string
+		for _, oc := range cov.Operations {
+			if oc.Count == 0 {
+				d = append(d, []string{oc.Method, oc.Path, "", "", oc.OperationID})
+				continue
+			}
+			for _, rc := range oc.Responses {
+				if rc.Count > 0 {
+					continue
+				}
+				d = append(d, []string{oc.Method, oc.Path, rc.Status, rc.ContentType, oc.OperationID})
+			}
+		}
+		if len(d) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintln(out, "  Untested:")
+		table := tablewriter.NewWriter(out)
+		table.SetHeader([]string{"Method", "Path", "Status", "Content-Type", "OperationID"})
+		table.SetAutoWrapText(false)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoFormatHeaders(false)
+		table.SetCenterSeparator("")
+		table.SetColumnSeparator("")
+		table.SetRowSeparator("")
+		table.SetHeaderLine(false)
+		table.SetBorder(false)
+		table.SetTablePadding("  ")
+		table.SetNoWhiteSpace(true)
+		table.AppendBulk(d)
+		table.Render()
+	}
+	return nil
+}
+
+// OutJSON outputs the coverage as JSON.
+func (covs OpenAPICoverages) OutJSON(out io.Writer) error {
+	b, err := json.MarshalIndent(covs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(b); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(out, ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckThreshold returns an error if the coverage of operations or responses is below the threshold (percent).
+func (covs OpenAPICoverages) CheckThreshold(threshold float64) error {
+	for _, cov := range covs {
+		if p := cov.OperationsPercent(); p < threshold {
+			return fmt.Errorf("openapi coverage of operations is below the threshold: %s: %.1f%% < %.1f%%", cov.Doc, p, threshold)
+		}
+		if p := cov.ResponsesPercent(); p < threshold {
+			return fmt.Errorf("openapi coverage of responses is below the threshold: %s: %.1f%% < %.1f%%", cov.Doc, p, threshold)
+		}
+	}
+	return nil
+}
+
+func coveragePercent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) / float64(total) * 100
+}
+
+func openAPIOperationKey(method, path string) string {
+	return fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+}
+
+func openAPIResponseKey(opKey, status, contentType string) string {
+	return fmt.Sprintf("%s %s %s", opKey, status, contentType)
+}
+
+// openAPIResponseStatus returns the key of responses that matches the status code ( exact, range such as `2XX` or `default` ).
+func openAPIResponseStatus(responses openapi3.Responses, code int) (string, bool) {
+	for _, s := range []string{strconv.Itoa(code), fmt.Sprintf("%dXX", code/100), "default"} {
+		if _, ok := responses[s]; ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// openAPIContentType returns the key of content that matches the content type ( exact, `type/*` or `*/*` ).
+func openAPIContentType(content openapi3.Content, contentType string) (string, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if _, ok := content[mt]; ok {
+			return mt, true
+		}
+		if i := strings.IndexByte(mt, '/'); i > 0 {
+			if _, ok := content[mt[:i]+"/*"]; ok {
+				return mt[:i] + "/*", true
+			}
+		}
+	}
+	if _, ok := content["*/*"]; ok {
+		return "*/*", true
+	}
+	return "", false
+}
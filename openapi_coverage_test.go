@@ -0,0 +1,139 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestOpenAPICoverage(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.HTTPServer(t)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.openAPICoverage = newOpenAPICoverage()
+	r, err := newHTTPRunner("req", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	v, err := newHttpValidator(&httpRunnerConfig{OpenApi3DocLocation: "testdata/openapi3.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.validator = v
+	o.httpRunners["req"] = r
+	o.registerOpenAPICoverage()
+
+	reqs := []*httpRequest{
+		{path: "/users", method: http.MethodGet, headers: map[string]string{}},
+		{path: "/users", method: http.MethodGet, headers: map[string]string{}},
+		{path: "/users/1", method: http.MethodGet, headers: map[string]string{}},
+	}
+	for _, req := range reqs {
+		if err := r.Run(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	covs := o.openAPICoverage.result()
+	if len(covs) != 1 {
+		t.Fatalf("got %v\nwant %v", len(covs), 1)
+	}
+	cov := covs[0]
+	if cov.Doc != "testdata/openapi3.yml" {
+		t.Errorf("got %v", cov.Doc)
+	}
+	if want := 2; cov.OperationsCovered != want {
+		t.Errorf("got %v\nwant %v", cov.OperationsCovered, want)
+	}
+	if want := 2; cov.ResponsesCovered != want {
+		t.Errorf("got %v\nwant %v", cov.ResponsesCovered, want)
+	}
+	for _, oc := range cov.Operations {
+		if oc.Method != http.MethodGet || oc.Path != "/users" {
+			continue
+		}
+		if oc.Count != 2 {
+			t.Errorf("got %v\nwant %v", oc.Count, 2)
+		}
+		if len(oc.Responses) != 1 || oc.Responses[0].Status != "200" || oc.Responses[0].ContentType != "application/json" || oc.Responses[0].Count != 2 {
+			t.Errorf("invalid responses: %#v", oc.Responses)
+		}
+	}
+
+	out := new(bytes.Buffer)
+	if err := covs.Out(out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "POST") || !strings.Contains(out.String(), "/upload") {
+		t.Errorf("untested operations are not listed: %s", out.String())
+	}
+	if err := covs.CheckThreshold(0); err != nil {
+		t.Error(err)
+	}
+	if err := covs.CheckThreshold(100); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestOpenAPIResponseStatus(t *testing.T) {
+	responses := openapi3.Responses{
+		"200":     &openapi3.ResponseRef{},
+		"4XX":     &openapi3.ResponseRef{},
+		"default": &openapi3.ResponseRef{},
+	}
+	tests := []struct {
+		code int
+		want string
+	}{
+		{200, "200"},
+		{404, "4XX"},
+		{500, "default"},
+	}
+	for _, tt := range tests {
+		got, ok := openAPIResponseStatus(responses, tt.code)
+		if !ok {
+			t.Errorf("%d: not found", tt.code)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestOpenAPIContentType(t *testing.T) {
+	content := openapi3.Content{
+		"application/json": openapi3.NewMediaType(),
+		"text/*":           openapi3.NewMediaType(),
+	}
+	tests := []struct {
+		contentType string
+		want        string
+		wantOK      bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/json; charset=utf-8", "application/json", true},
+		{"text/html; charset=utf-8", "text/*", true},
+		{"image/png", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := openAPIContentType(content, tt.contentType)
+		if ok != tt.wantOK {
+			t.Errorf("%s: got %v\nwant %v", tt.contentType, ok, tt.wantOK)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+)
+
+// openAPI31UnsupportedKeywords are JSON Schema 2020-12 keywords that cannot be expressed in OpenAPI 3.0.
+var openAPI31UnsupportedKeywords = []string{
+	"$defs", "$dynamicAnchor", "$dynamicRef",
+	"prefixItems", "contains", "minContains", "maxContains", "unevaluatedItems",
+	"patternProperties", "propertyNames", "unevaluatedProperties",
+	"dependentRequired", "dependentSchemas",
+	"if", "then", "else",
+}
+
+// openAPI31AnnotationKeywords are JSON Schema 2020-12 keywords that do not affect validation.
+var openAPI31AnnotationKeywords = []string{
+	"$schema", "$id", "$anchor", "$comment",
+	"contentMediaType", "contentEncoding", "contentSchema",
+}
+
+// loadOpenAPI3Doc loads and validates the OpenAPI 3.0 or 3.1 document from the local path or URL.
+func loadOpenAPI3Doc(l string) (*openapi3.T, error) {
+	b, u, err := readOpenAPIDoc(l)
+	if err != nil {
+		return nil, err
+	}
+	return parseOpenAPI3Doc(b, u)
+}
+
+// loadSwagger2Doc loads and validates the Swagger 2.0 document from the local path or URL, and converts it to OpenAPI 3.0.
+func loadSwagger2Doc(l string) (*openapi3.T, error) {
+	b, _, err := readOpenAPIDoc(l)
+	if err != nil {
+		return nil, err
+	}
+	return parseSwagger2Doc(b)
+}
+
+func readOpenAPIDoc(l string) ([]byte, *url.URL, error) {
+	if !strings.HasPrefix(l, "https://") && !strings.HasPrefix(l, "http://") {
+		b, err := readFile(l)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, nil, nil
+	}
+	u, err := url.Parse(l)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := openapi3.ReadFromHTTP(http.DefaultClient)(openapi3.NewLoader(), u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, u, nil
+}
+
+// parseOpenAPI3Doc parses the OpenAPI 3.0 or 3.1 document. The OpenAPI 3.1 document is converted to OpenAPI 3.0.
+func parseOpenAPI3Doc(b []byte, location *url.URL) (*openapi3.T, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi3 document: %w", err)
+	}
+	if _, ok := raw["swagger"]; ok {
+		return nil, errors.New("the document is Swagger 2.0. use `swagger2:` instead of `openapi3:`")
+	}
+	if v, ok := raw["openapi"].(string); ok && strings.HasPrefix(v, "3.1") {
+		if err := convertOpenAPI31To30(raw); err != nil {
+			return nil, fmt.Errorf("failed to convert openapi 3.1 document: %w", err)
+		}
+		var err error
+		b, err = json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ctx := context.Background()
+	loader := openapi3.NewLoader()
+	var (
+		doc *openapi3.T
+		err error
+	)
+	if location != nil {
+		doc, err = loader.LoadFromDataWithPath(b, location)
+	} else {
+		doc, err = loader.LoadFromData(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("openapi3 document validation error: %w", err)
+	}
+	return doc, nil
+}
+
+// parseSwagger2Doc parses the Swagger 2.0 document and converts it to OpenAPI 3.0.
+func parseSwagger2Doc(b []byte) (*openapi3.T, error) {
+	jb, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse swagger2 document: %w", err)
+	}
+	doc2 := &openapi2.T{}
+	if err := json.Unmarshal(jb, doc2); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger2 document: %w", err)
+	}
+	if doc2.Swagger == "" {
+		return nil, errors.New("the document is not Swagger 2.0. use `openapi3:` instead of `swagger2:`")
+	}
+	doc, err := openapi2conv.ToV3(doc2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert swagger2 document: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("swagger2 document validation error: %w", err)
+	}
+	return doc, nil
+}
+
+// convertOpenAPI31To30 converts the OpenAPI 3.1 document ( unmarshaled ) to OpenAPI 3.0 in place.
+func convertOpenAPI31To30(doc map[string]any) error {
+	delete(doc, "webhooks")
+	delete(doc, "jsonSchemaDialect")
+	if _, ok := doc["paths"]; !ok {
+		doc["paths"] = map[string]any{}
+	}
+	if info, ok := doc["info"].(map[string]any); ok {
+		delete(info, "summary")
+		if l, ok := info["license"].(map[string]any); ok {
+			delete(l, "identifier")
+		}
+	}
+	if c, ok := doc["components"].(map[string]any); ok {
+		delete(c, "pathItems")
+	}
+	return walkOpenAPI31(doc, "#")
+}
+
+// walkOpenAPI31 walks the OpenAPI 3.1 document and converts schemas.
+func walkOpenAPI31(v any, ptr string) error {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, e := range vv {
+			p := joinJSONPointer(ptr, k)
+			switch {
+			case k == "example" || k == "examples":
+				// values of examples are not the part of the document
+				continue
+			case k == "schema":
+				s, err := convertOpenAPI31Schema(e, p)
+				if err != nil {
+					return err
+				}
+				vv[k] = s
+			case k == "schemas" && ptr == "#/components":
+				schemas, ok := e.(map[string]any)
+				if !ok {
+					return fmt.Errorf("%s: invalid schemas: %v", p, e)
+				}
+				for n, s := range schemas {
+					cs, err := convertOpenAPI31Schema(s, joinJSONPointer(p, n))
+					if err != nil {
+						return err
+					}
+					schemas[n] = cs
+				}
+			default:
+				if err := walkOpenAPI31(e, p); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for i, e := range vv {
+			if err := walkOpenAPI31(e, fmt.Sprintf("%s/%d", ptr, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convertOpenAPI31Schema converts the JSON Schema 2020-12 schema to the OpenAPI 3.0 schema.
+func convertOpenAPI31Schema(v any, ptr string) (any, error) {
+	if b, ok := v.(bool); ok {
+		// boolean schema
+		if b {
+			return map[string]any{}, nil
+		}
+		return map[string]any{"not": map[string]any{}}, nil
+	}
+	s, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid schema: %v", ptr, v)
+	}
+
+	if ref, ok := s["$ref"]; ok {
+		// In OpenAPI 3.1, $ref can have sibling keywords
+		delete(s, "$ref")
+		delete(s, "description")
+		delete(s, "summary")
+		if len(s) == 0 {
+			return map[string]any{"$ref": ref}, nil
+		}
+		rest, err := convertOpenAPI31Schema(s, ptr)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"allOf": []any{map[string]any{"$ref": ref}, rest}}, nil
+	}
+
+	for _, k := range openAPI31UnsupportedKeywords {
+		if _, ok := s[k]; ok {
+			return nil, fmt.Errorf("%s: unsupported keyword in openapi 3.1 document", joinJSONPointer(ptr, k))
+		}
+	}
+	for _, k := range openAPI31AnnotationKeywords {
+		delete(s, k)
+	}
+
+	// type
+	switch t := s["type"].(type) {
+	case nil:
+	case string:
+		if t == "null" {
+			delete(s, "type")
+			s["nullable"] = true
+		}
+	case []any:
+		var types []string
+		for _, tt := range t {
+			ts, ok := tt.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: invalid type: %v", joinJSONPointer(ptr, "type"), t)
+			}
+			if ts == "null" {
+				s["nullable"] = true
+				continue
+			}
+			types = append(types, ts)
+		}
+		delete(s, "type")
+		switch len(types) {
+		case 0:
+		case 1:
+			s["type"] = types[0]
+		default:
+			if _, ok := s["anyOf"]; ok {
+				return nil, fmt.Errorf("%s: multiple types cannot be used with anyOf", joinJSONPointer(ptr, "type"))
+			}
+			var anyOf []any
+			for _, ts := range types {
+				anyOf = append(anyOf, map[string]any{"type": ts})
+			}
+			s["anyOf"] = anyOf
+		}
+	default:
+		return nil, fmt.Errorf("%s: invalid type: %v", joinJSONPointer(ptr, "type"), t)
+	}
+
+	// const
+	if c, ok := s["const"]; ok {
+		delete(s, "const")
+		s["enum"] = []any{c}
+	}
+
+	// examples
+	if e, ok := s["examples"]; ok {
+		delete(s, "examples")
+		es, ok := e.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid examples: %v", joinJSONPointer(ptr, "examples"), e)
+		}
+		if _, ok := s["example"]; !ok && len(es) > 0 {
+			s["example"] = es[0]
+		}
+	}
+
+	// exclusiveMinimum and exclusiveMaximum
+	for k, b := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		e, ok := s[k]
+		if !ok {
+			continue
+		}
+		if _, ok := e.(bool); ok {
+			continue
+		}
+		s[b] = e
+		s[k] = true
+	}
+
+	// subschemas
+	for _, k := range []string{"items", "not"} {
+		if e, ok := s[k]; ok {
+			cs, err := convertOpenAPI31Schema(e, joinJSONPointer(ptr, k))
+			if err != nil {
+				return nil, err
+			}
+			s[k] = cs
+		}
+	}
+	if e, ok := s["additionalProperties"]; ok {
+		if _, ok := e.(bool); !ok {
+			cs, err := convertOpenAPI31Schema(e, joinJSONPointer(ptr, "additionalProperties"))
+			if err != nil {
+				return nil, err
+			}
+			s["additionalProperties"] = cs
+		}
+	}
+	for _, k := range []string{"allOf", "anyOf", "oneOf"} {
+		e, ok := s[k]
+		if !ok {
+			continue
+		}
+		es, ok := e.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid %s: %v", joinJSONPointer(ptr, k), k, e)
+		}
+		for i, ee := range es {
+			cs, err := convertOpenAPI31Schema(ee, fmt.Sprintf("%s/%d", joinJSONPointer(ptr, k), i))
+			if err != nil {
+				return nil, err
+			}
+			es[i] = cs
+		}
+	}
+	if e, ok := s["properties"]; ok {
+		props, ok := e.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid properties: %v", joinJSONPointer(ptr, "properties"), e)
+		}
+		for n, p := range props {
+			cs, err := convertOpenAPI31Schema(p, joinJSONPointer(joinJSONPointer(ptr, "properties"), n))
+			if err != nil {
+				return nil, err
+			}
+			props[n] = cs
+		}
+	}
+	return s, nil
+}
+
+func joinJSONPointer(ptr, key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	key = strings.ReplaceAll(key, "/", "~1")
+	return fmt.Sprintf("%s/%s", ptr, key)
+}
@@ -0,0 +1,230 @@
+package runn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const validOpenApi31Spec = `
+openapi: 3.1.0
+info:
+  title: test spec
+  version: 0.0.1
+  summary: OpenAPI 3.1
+  license:
+    name: MIT
+    identifier: MIT
+paths:
+  /users:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+      responses:
+        '201':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+              examples:
+                alice:
+                  value:
+                    username: alice
+                    schema: not a schema
+webhooks:
+  newUser:
+    post:
+      responses:
+        '200':
+          description: OK
+components:
+  schemas:
+    User:
+      $schema: https://json-schema.org/draft/2020-12/schema
+      type: object
+      properties:
+        username:
+          type: string
+          examples:
+            - alice
+        nickname:
+          type:
+            - string
+            - 'null'
+        age:
+          type: integer
+          exclusiveMinimum: 0
+        role:
+          const: member
+      required:
+        - username
+`
+
+const validSwagger2Spec = `
+swagger: '2.0'
+info:
+  title: test spec
+  version: 0.0.1
+host: example.com
+basePath: /
+consumes:
+  - application/json
+produces:
+  - application/json
+paths:
+  /users:
+    post:
+      parameters:
+        - in: body
+          name: body
+          required: true
+          schema:
+            $ref: '#/definitions/User'
+      responses:
+        '201':
+          description: OK
+          schema:
+            $ref: '#/definitions/User'
+definitions:
+  User:
+    type: object
+    properties:
+      username:
+        type: string
+    required:
+      - username
+`
+
+func TestOpenApi31And2Validator(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     httpRunnerOption
+		reqBody string
+		resBody string
+		wantErr bool
+	}{
+		{"3.1 valid", OpenApi3FromData([]byte(validOpenApi31Spec)), `{"username": "alice", "nickname": null, "age": 1, "role": "member"}`, `{"username": "alice"}`, false},
+		{"3.1 null is not allowed", OpenApi3FromData([]byte(validOpenApi31Spec)), `{"username": null}`, `{"username": "alice"}`, true},
+		{"3.1 exclusiveMinimum", OpenApi3FromData([]byte(validOpenApi31Spec)), `{"username": "alice", "age": 0}`, `{"username": "alice"}`, true},
+		{"3.1 const", OpenApi3FromData([]byte(validOpenApi31Spec)), `{"username": "alice", "role": "admin"}`, `{"username": "alice"}`, true},
+		{"3.1 invalid response", OpenApi3FromData([]byte(validOpenApi31Spec)), `{"username": "alice"}`, `{"nickname": "alice"}`, true},
+		{"2.0 valid", Swagger2FromData([]byte(validSwagger2Spec)), `{"username": "alice"}`, `{"username": "alice"}`, false},
+		{"2.0 invalid request", Swagger2FromData([]byte(validSwagger2Spec)), `{"nickname": "alice"}`, `{"username": "alice"}`, true},
+		{"2.0 invalid response", Swagger2FromData([]byte(validSwagger2Spec)), `{"username": "alice"}`, `{"username": 1}`, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &httpRunnerConfig{}
+			if err := tt.opt(c); err != nil {
+				t.Fatal(err)
+			}
+			v, err := newOpenApi3Validator(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &http.Request{
+				Method: http.MethodPost,
+				URL:    pathToURL(t, "/users"),
+				Header: http.Header{"Content-Type": []string{"application/json"}},
+				Body:   io.NopCloser(strings.NewReader(tt.reqBody)),
+			}
+			res := &http.Response{
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(tt.resBody)),
+			}
+			err = v.ValidateRequest(ctx, req)
+			if err == nil {
+				err = v.ValidateResponse(ctx, req, res)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseOpenAPIDocError(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  httpRunnerOption
+		want string
+	}{
+		{"swagger2 as openapi3", OpenApi3FromData([]byte(validSwagger2Spec)), "use `swagger2:`"},
+		{"openapi3 as swagger2", Swagger2FromData([]byte(validOpenApi31Spec)), "use `openapi3:`"},
+		{
+			"unsupported keyword",
+			OpenApi3FromData([]byte(strings.Replace(validOpenApi31Spec, "        age:\n", "        age:\n          prefixItems: []\n", 1))),
+			"#/components/schemas/User/properties/age/prefixItems: unsupported keyword",
+		},
+		{
+			"invalid type",
+			OpenApi3FromData([]byte(strings.Replace(validOpenApi31Spec, "          type: integer\n", "          type: [1]\n", 1))),
+			"#/components/schemas/User/properties/age/type: invalid type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &httpRunnerConfig{}
+			err := tt.opt(c)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v\nwant %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertOpenAPI31Schema(t *testing.T) {
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{true, map[string]any{}},
+		{false, map[string]any{"not": map[string]any{}}},
+		{
+			map[string]any{"type": "null"},
+			map[string]any{"nullable": true},
+		},
+		{
+			map[string]any{"type": []any{"string", "integer", "null"}},
+			map[string]any{"nullable": true, "anyOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}}},
+		},
+		{
+			map[string]any{"type": "integer", "exclusiveMaximum": 10, "$comment": "comment"},
+			map[string]any{"type": "integer", "maximum": 10, "exclusiveMaximum": true},
+		},
+		{
+			map[string]any{"$ref": "#/components/schemas/User", "description": "user"},
+			map[string]any{"$ref": "#/components/schemas/User"},
+		},
+		{
+			map[string]any{"$ref": "#/components/schemas/User", "nullable": true},
+			map[string]any{"allOf": []any{map[string]any{"$ref": "#/components/schemas/User"}, map[string]any{"nullable": true}}},
+		},
+		{
+			map[string]any{"type": "array", "items": map[string]any{"const": "a", "examples": []any{"a"}}},
+			map[string]any{"type": "array", "items": map[string]any{"enum": []any{"a"}, "example": "a"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := convertOpenAPI31Schema(tt.in, "#")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"io"
 	"math"
 	"math/rand"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -33,6 +32,7 @@ type operator struct {
 	httpRunners map[string]*httpRunner
 	dbRunners   map[string]*dbRunner
 	grpcRunners map[string]*grpcRunner
+	wsRunners   map[string]*wsRunner
 	cdpRunners  map[string]*cdpRunner
 	sshRunners  map[string]*sshRunner
 	steps       []*step
@@ -67,6 +67,12 @@ type operator struct {
 	sw            *stopw.Span
 	capturers     capturers
 	runResult     *RunResult
+	// Coverage of OpenAPI documents shared by all runbooks
+	openAPICoverage *openAPICoverage
+	// HTTP validators that can be referred by name from HTTP runners
+	httpValidators map[string]HTTPValidator
+	// HTTP validators appended to HTTP runners
+	httpRunnerValidators map[string][]HTTPValidator
 
 	mu sync.Mutex
 }
@@ -104,6 +110,9 @@ func (o *operator) Close(force bool) {
 		}
 		_ = r.Close()
 	}
+	for _, r := range o.wsRunners {
+		_ = r.Close()
+	}
 	for _, r := range o.cdpRunners {
 		_ = r.Close()
 	}
@@ -171,9 +180,11 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 			if err != nil {
 				return err
 			}
-			if err := s.httpRunner.Run(ctx, req); err != nil {
+			timer := newHTTPTimer()
+			if err := s.httpRunner.run(ctx, req, timer); err != nil {
 				return fmt.Errorf("http request failed on %s: %w", o.stepName(i), err)
 			}
+			o.recordHTTPTimingsToProfile(s.trails(), timer)
 			run = true
 		case s.dbRunner != nil && s.dbQuery != nil:
 			e, err := o.expandBeforeRecord(s.dbQuery)
@@ -201,6 +212,15 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 				return fmt.Errorf("gRPC request failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
+		case s.wsRunner != nil && s.wsRequest != nil:
+			req, err := parseWSRequest(s.wsRequest, o.expandBeforeRecord)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v: %w", o.stepName(i), s.wsRequest, err)
+			}
+			if err := s.wsRunner.Run(ctx, req); err != nil {
+				return fmt.Errorf("WebSocket request failed on %s: %w", o.stepName(i), err)
+			}
+			run = true
 		case s.cdpRunner != nil && s.cdpActions != nil:
 			cas, err := parseCDPActions(s.cdpActions, o.expandBeforeRecord)
 			if err != nil {
@@ -395,10 +415,6 @@ func (o *operator) recordToLatest(key string, value any) error {
 	return o.store.recordToLatest(key, value)
 }
 
-func (o *operator) recordToCookie(cookies []*http.Cookie) {
-	o.store.recordToCookie(cookies)
-}
-
 func (o *operator) generateTrail() Trail {
 	return Trail{
 		Type:        TrailTypeRunbook,
@@ -417,30 +433,58 @@ func (o *operator) trails() Trails {
 	return trs
 }
 
+// recordHTTPTimingsToProfile records the timing breakdown of the HTTP request as the breakdown of the step.
+func (o *operator) recordHTTPTimingsToProfile(trs Trails, t *httpTimer) {
+	if t == nil {
+		return
+	}
+	for _, p := range t.phases() {
+		// The name of the phase is recorded to Desc
+		ids := append(trs, Trail{
+			Type: TrailTypeHTTPTiming,
+			Desc: p.name,
+		}).toInterfaceSlice()
+		o.sw.StartAt(p.start, ids...)
+		o.sw.StopAt(p.end, ids...)
+	}
+}
+
 // New returns *operator.
 func New(opts ...Option) (*operator, error) {
 	bk := newBook()
 	if err := bk.applyOptions(opts...); err != nil {
 		return nil, err
 	}
+	bk.resolveHTTPValidators()
 	id, err := generateID(bk.path)
 	if err != nil {
 		return nil, err
 	}
+	jar := bk.cookieJar
+	if jar == nil {
+		jar = newCookieJar()
+	}
+	if bk.cookieJarFile != "" {
+		if err := jar.setFile(bk.cookieJarFile); err != nil {
+			return nil, err
+		}
+	}
 	o := &operator{
 		id:          id,
 		httpRunners: map[string]*httpRunner{},
 		dbRunners:   map[string]*dbRunner{},
 		grpcRunners: map[string]*grpcRunner{},
+		wsRunners:   map[string]*wsRunner{},
 		cdpRunners:  map[string]*cdpRunner{},
 		sshRunners:  map[string]*sshRunner{},
 		store: store{
-			steps:    []map[string]any{},
-			stepMap:  map[string]map[string]any{},
-			vars:     bk.vars,
-			funcs:    bk.funcs,
-			bindVars: map[string]any{},
-			useMap:   bk.useMap,
+			steps:     []map[string]any{},
+			stepMap:   map[string]map[string]any{},
+			vars:      bk.vars,
+			funcs:     bk.funcs,
+			bindVars:  map[string]any{},
+			useMap:    bk.useMap,
+			cookieJar: jar,
 		},
 		useMap:      bk.useMap,
 		desc:        bk.desc,
@@ -465,6 +509,11 @@ func New(opts ...Option) (*operator, error) {
 		sw:          stopw.New(),
 		capturers:   bk.capturers,
 		runResult:   newRunResult(bk.desc, bk.path),
+
+		openAPICoverage: bk.openAPICoverage,
+		httpValidators:  bk.httpValidators,
+
+		httpRunnerValidators: bk.httpRunnerValidators,
 	}
 
 	if o.debug {
@@ -496,8 +545,13 @@ func New(opts ...Option) (*operator, error) {
 		}
 		v.protos = append([]string{}, bk.grpcProtos...)
 		v.importPaths = append([]string{}, bk.grpcImportPaths...)
+		v.protosets = unique(append(v.protosets, bk.grpcProtosets...))
 		o.grpcRunners[k] = v
 	}
+	for k, v := range bk.wsRunners {
+		v.operator = o
+		o.wsRunners[k] = v
+	}
 	for k, v := range bk.cdpRunners {
 		v.operator = o
 		o.cdpRunners[k] = v
@@ -523,6 +577,12 @@ func New(opts ...Option) (*operator, error) {
 		}
 		keys[k] = struct{}{}
 	}
+	for k := range o.wsRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", o.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
 	for k := range o.cdpRunners {
 		if _, ok := keys[k]; ok {
 			return nil, fmt.Errorf("duplicate runner names (%s): %s", o.bookPath, k)
@@ -714,6 +774,16 @@ func (o *operator) AppendStep(key string, s map[string]any) error {
 				step.grpcRequest = vv
 				detected = true
 			}
+			wc, ok := o.wsRunners[k]
+			if ok && !detected {
+				step.wsRunner = wc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid WebSocket request: %v", v)
+				}
+				step.wsRequest = vv
+				detected = true
+			}
 			cc, ok := o.cdpRunners[k]
 			if ok && !detected {
 				step.cdpRunner = cc
@@ -943,6 +1013,11 @@ func (o *operator) runInternal(ctx context.Context) (rerr error) {
 	o.store.clearSteps()
 
 	defer func() {
+		// save cookies
+		if err := o.store.cookieJar.save(); err != nil && rerr == nil {
+			rerr = err
+		}
+
 		// set run error and skipped
 		o.runResult.Err = rerr
 		o.runResult.Skipped = o.Skipped()
@@ -1144,7 +1219,9 @@ type operators struct {
 	opts        []Option
 	results     []*runNResult
 	runCount    int64
-	mu          sync.Mutex
+	// Coverage of OpenAPI documents
+	openAPICoverage *openAPICoverage
+	mu              sync.Mutex
 }
 
 func Load(pathp string, opts ...Option) (*operators, error) {
@@ -1171,6 +1248,8 @@ func Load(pathp string, opts ...Option) (*operators, error) {
 	if bk.runConcurrent {
 		ops.concmax = bk.runConcurrentMax
 	}
+	ops.openAPICoverage = newOpenAPICoverage()
+	opts = append(opts, runnOpenAPICoverage(ops.openAPICoverage))
 	books, err := Books(pathp)
 	if err != nil {
 		return nil, err
@@ -1229,6 +1308,10 @@ func Load(pathp string, opts ...Option) (*operators, error) {
 
 	// Fix order of running
 	sortOperators(ops.ops)
+
+	for _, o := range ops.ops {
+		o.registerOpenAPICoverage()
+	}
 	return ops, nil
 }
 
@@ -1248,6 +1331,11 @@ func (ops *operators) RunN(ctx context.Context) error {
 	return nil
 }
 
+// OpenAPICoverages returns the coverage of OpenAPI documents used by HTTP runners of runbooks.
+func (ops *operators) OpenAPICoverages() OpenAPICoverages {
+	return ops.openAPICoverage.result()
+}
+
 func (ops *operators) Operators() []*operator {
 	return ops.ops
 }
@@ -591,7 +591,7 @@ func TestShard(t *testing.T) {
 			}
 			sortOperators(got)
 			allow := []any{
-				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{},
+				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, wsRunner{},
 			}
 			ignore := []any{
 				step{}, store{}, sql.DB{}, os.File{}, stopw.Span{}, debugger{}, nest.DB{}, Loop{},
@@ -603,6 +603,7 @@ func TestShard(t *testing.T) {
 				cmpopts.IgnoreFields(operator{}, "id"),
 				cmpopts.IgnoreFields(operator{}, "concurrency"),
 				cmpopts.IgnoreFields(operator{}, "mu"),
+				cmpopts.IgnoreFields(operator{}, "openAPICoverage"),
 				cmpopts.IgnoreFields(cdpRunner{}, "ctx"),
 				cmpopts.IgnoreFields(cdpRunner{}, "cancel"),
 				cmpopts.IgnoreFields(cdpRunner{}, "opts"),
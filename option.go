@@ -64,6 +64,9 @@ func Overlay(path string) Option {
 		for k, r := range loaded.grpcRunners {
 			bk.grpcRunners[k] = r
 		}
+		for k, r := range loaded.wsRunners {
+			bk.wsRunners[k] = r
+		}
 		for k, r := range loaded.cdpRunners {
 			bk.cdpRunners[k] = r
 		}
@@ -129,6 +132,11 @@ func Underlay(path string) Option {
 				bk.grpcRunners[k] = r
 			}
 		}
+		for k, r := range loaded.wsRunners {
+			if _, ok := bk.wsRunners[k]; !ok {
+				bk.wsRunners[k] = r
+			}
+		}
 		for k, r := range loaded.cdpRunners {
 			if _, ok := bk.cdpRunners[k]; !ok {
 				bk.cdpRunners[k] = r
@@ -187,6 +195,10 @@ func Runner(name, dsn string, opts ...httpRunnerOption) Option {
 				return nil
 			}
 		}
+		if c.Endpoint != "" {
+			bk.runnerErrs[name] = errors.New("HTTPEndpoint is only supported by HTTPRunnerWithHandler")
+			return nil
+		}
 		r, err := newHTTPRunner(name, dsn)
 		if err != nil {
 			bk.runnerErrs[name] = err
@@ -202,7 +214,17 @@ func Runner(name, dsn string, opts ...httpRunnerOption) Option {
 				return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
 			}
 		}
-		if c.OpenApi3DocLocation != "" {
+		if err := r.setConnectionOptions(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		a, err := newHTTPAuth(c.Auth)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		r.auth = a
+		if c.OpenApi3DocLocation != "" || c.Swagger2DocLocation != "" {
 			v, err := newHttpValidator(c)
 			if err != nil {
 				bk.runnerErrs[name] = err
@@ -210,6 +232,8 @@ func Runner(name, dsn string, opts ...httpRunnerOption) Option {
 			}
 			r.validator = v
 		}
+		r.validatorNames = c.Validators
+		r.failOnGraphQLErrors = c.FailOnGraphQLErrors
 		bk.httpRunners[name] = r
 		return nil
 	}
@@ -239,6 +263,10 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 				return nil
 			}
 		}
+		if c.Endpoint != "" {
+			bk.runnerErrs[name] = errors.New("HTTPEndpoint is only supported by HTTPRunnerWithHandler")
+			return nil
+		}
 
 		if c.NotFollowRedirect {
 			r.client.CheckRedirect = notFollowRedirectFn
@@ -247,6 +275,9 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 		if c.OpenApi3DocLocation != "" && !strings.HasPrefix(c.OpenApi3DocLocation, "https://") && !strings.HasPrefix(c.OpenApi3DocLocation, "http://") && !strings.HasPrefix(c.OpenApi3DocLocation, "/") {
 			c.OpenApi3DocLocation = fp(c.OpenApi3DocLocation, root)
 		}
+		if c.Swagger2DocLocation != "" && !strings.HasPrefix(c.Swagger2DocLocation, "https://") && !strings.HasPrefix(c.Swagger2DocLocation, "http://") && !strings.HasPrefix(c.Swagger2DocLocation, "/") {
+			c.Swagger2DocLocation = fp(c.Swagger2DocLocation, root)
+		}
 		if c.CACert != "" {
 			b, err := readFile(fp(c.CACert, root))
 			if err != nil {
@@ -276,6 +307,16 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 			}
 		}
 		r.useCookie = c.UseCookie
+		if err := r.setConnectionOptions(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		a, err := newHTTPAuth(c.Auth)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		r.auth = a
 
 		hv, err := newHttpValidator(c)
 		if err != nil {
@@ -283,6 +324,8 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 			return nil
 		}
 		r.validator = hv
+		r.validatorNames = c.Validators
+		r.failOnGraphQLErrors = c.FailOnGraphQLErrors
 		return nil
 	}
 }
@@ -296,37 +339,98 @@ func HTTPRunnerWithHandler(name string, h http.Handler, opts ...httpRunnerOption
 			bk.runnerErrs[name] = err
 			return nil
 		}
-		if len(opts) > 0 {
-			c := &httpRunnerConfig{}
-			for _, opt := range opts {
-				if err := opt(c); err != nil {
-					bk.runnerErrs[name] = err
-					return nil
-				}
-			}
-			if c.NotFollowRedirect {
-				bk.runnerErrs[name] = errors.New("HTTPRunnerWithHandler does not support option NotFollowRedirect")
+		if len(opts) == 0 {
+			bk.httpRunners[name] = r
+			return nil
+		}
+		root, err := bk.generateOperatorRoot()
+		if err != nil {
+			return err
+		}
+		c := &httpRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
 				return nil
 			}
-			r.multipartBoundary = c.MultipartBoundary
-			if c.Timeout != "" {
-				r.client.Timeout, err = duration.Parse(c.Timeout)
-				if err != nil {
-					return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
-				}
-			}
-			v, err := newHttpValidator(c)
+		}
+		if c.Endpoint != "" {
+			u, err := url.Parse(c.Endpoint)
 			if err != nil {
 				bk.runnerErrs[name] = err
 				return nil
 			}
-			r.validator = v
+			r.endpoint = u
+		}
+		if c.NotFollowRedirect {
+			r.client.CheckRedirect = notFollowRedirectFn
+		}
+		r.multipartBoundary = c.MultipartBoundary
+		if c.OpenApi3DocLocation != "" && !strings.HasPrefix(c.OpenApi3DocLocation, "https://") && !strings.HasPrefix(c.OpenApi3DocLocation, "http://") && !strings.HasPrefix(c.OpenApi3DocLocation, "/") {
+			c.OpenApi3DocLocation = fp(c.OpenApi3DocLocation, root)
+		}
+		if c.Swagger2DocLocation != "" && !strings.HasPrefix(c.Swagger2DocLocation, "https://") && !strings.HasPrefix(c.Swagger2DocLocation, "http://") && !strings.HasPrefix(c.Swagger2DocLocation, "/") {
+			c.Swagger2DocLocation = fp(c.Swagger2DocLocation, root)
+		}
+		if c.Timeout != "" {
+			r.client.Timeout, err = duration.Parse(c.Timeout)
+			if err != nil {
+				return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
+			}
+		}
+		r.useCookie = c.UseCookie
+		if err := validateHandlerConnectionOptions(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		a, err := newHTTPAuth(c.Auth)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
 		}
+		r.auth = a
+		v, err := newHttpValidator(c)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		r.validator = v
+		r.validatorNames = c.Validators
+		r.failOnGraphQLErrors = c.FailOnGraphQLErrors
 		bk.httpRunners[name] = r
 		return nil
 	}
 }
 
+// HTTPRunnerValidator - Append HTTP validator to the HTTP runner. Validators are run in order after the OpenAPI validator.
+// If the runbook does not have the HTTP runner, the validator is not used.
+func HTTPRunnerValidator(name string, v HTTPValidator) Option {
+	return func(bk *book) error {
+		if v == nil {
+			return fmt.Errorf("http validator is nil: %s", name)
+		}
+		if bk.httpRunnerValidators == nil {
+			bk.httpRunnerValidators = map[string][]HTTPValidator{}
+		}
+		bk.httpRunnerValidators[name] = append(bk.httpRunnerValidators[name], v)
+		return nil
+	}
+}
+
+// NamedHTTPValidator - Set HTTP validator that can be referred by name from `validators:` of HTTP runners in runbooks.
+func NamedHTTPValidator(name string, v HTTPValidator) Option {
+	return func(bk *book) error {
+		if v == nil {
+			return fmt.Errorf("http validator is nil: %s", name)
+		}
+		if bk.httpValidators == nil {
+			bk.httpValidators = map[string]HTTPValidator{}
+		}
+		bk.httpValidators[name] = v
+		return nil
+	}
+}
+
 // DBRunner - Set DB runner to runbook.
 func DBRunner(name string, client Querier) Option {
 	return func(bk *book) error {
@@ -407,13 +511,81 @@ func GrpcRunnerWithOptions(name, target string, opts ...grpcRunnerOption) Option
 			}
 			r.importPaths = c.ImportPaths
 			r.protos = c.Protos
+			r.protosets = c.Protosets
+			r.bufImage = c.BufImage
 			r.skipVerify = c.SkipVerify
+			p, err := newRunnerProxy(c.Proxy, c.NoProxy)
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			r.proxy = p
+			hr, err := newHostResolver(c.Resolve)
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			r.resolver = hr
+			if err := validateGRPCProtocol(c.Protocol, c.Codec); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			if err := validateGRPCOverHTTPOptions(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			r.protocol = c.Protocol
+			r.codec = c.Codec
+			dopts, err := newGrpcDialOptions(c)
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			r.dialOpts = dopts
+			if c.Credentials != nil {
+				creds, err := newGrpcPerRPCCredentials(c.Credentials)
+				if err != nil {
+					bk.runnerErrs[name] = err
+					return nil
+				}
+				r.perRPCCreds = creds
+			}
+			r.serverName = c.ServerName
 		}
 		bk.grpcRunners[name] = r
 		return nil
 	}
 }
 
+// WSRunner - Set WebSocket runner to runbook.
+func WSRunner(name, endpoint string, opts ...wsRunnerOption) Option {
+	return func(bk *book) error {
+		delete(bk.runnerErrs, name)
+		root, err := bk.generateOperatorRoot()
+		if err != nil {
+			return err
+		}
+		r, err := newWSRunner(name, endpoint)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		c := &wsRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if err := r.setConfig(c, root); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		bk.wsRunners[name] = r
+		return nil
+	}
+}
+
 // SSHRunner - Set SSH runner to runbook.
 func SSHRunner(name string, client *ssh.Client) Option {
 	return func(bk *book) error {
@@ -655,6 +827,14 @@ func GRPCImportPaths(paths []string) Option {
 	}
 }
 
+// GRPCProtosets - Set the path of protoset ( FileDescriptorSet ) for all gRPC runners.
+func GRPCProtosets(protosets []string) Option {
+	return func(bk *book) error {
+		bk.grpcProtosets = protosets
+		return nil
+	}
+}
+
 // BeforeFunc - Register the function to be run before the runbook is run.
 func BeforeFunc(fn func(*RunResult) error) Option {
 	return func(bk *book) error {
@@ -790,6 +970,33 @@ func Stderr(w io.Writer) Option {
 	}
 }
 
+// ShareCookies - Share the cookie jar across all runbooks loaded with the same options.
+func ShareCookies(share bool) Option {
+	if !share {
+		return func(bk *book) error {
+			return nil
+		}
+	}
+	jar := newCookieJar()
+	return func(bk *book) error {
+		bk.cookieJar = jar
+		return nil
+	}
+}
+
+// CookieJarFile - Set the file to load cookies from and save persistent cookies to.
+// The cookie jar is shared across all runbooks loaded with the same options so that cookies saved by other runbooks are not lost.
+func CookieJarFile(p string) Option {
+	jar := newCookieJar()
+	return func(bk *book) error {
+		bk.cookieJarFile = p
+		if bk.cookieJar == nil {
+			bk.cookieJar = jar
+		}
+		return nil
+	}
+}
+
 // LoadOnly - Load only.
 func LoadOnly() Option {
 	return func(bk *book) error {
@@ -864,8 +1071,26 @@ func Books(pathp string) ([]Option, error) {
 	return opts, nil
 }
 
+func runnOpenAPICoverage(c *openAPICoverage) Option {
+	return func(bk *book) error {
+		bk.openAPICoverage = c
+		return nil
+	}
+}
+
+func runnCookieJar(jar *cookieJar) Option {
+	return func(bk *book) error {
+		bk.cookieJar = jar
+		return nil
+	}
+}
+
 func runnHTTPRunner(name string, r *httpRunner) Option {
 	return func(bk *book) error {
+		if bk.inheritedHTTPRunners == nil {
+			bk.inheritedHTTPRunners = map[string]*httpRunner{}
+		}
+		bk.inheritedHTTPRunners[name] = r
 		bk.httpRunners[name] = r
 		return nil
 	}
@@ -885,6 +1110,13 @@ func runnGrpcRunner(name string, r *grpcRunner) Option {
 	}
 }
 
+func runnWSRunner(name string, r *wsRunner) Option {
+	return func(bk *book) error {
+		bk.wsRunners[name] = r
+		return nil
+	}
+}
+
 func runnSSHRunner(name string, r *sshRunner) Option {
 	return func(bk *book) error {
 		bk.sshRunners[name] = r
@@ -2,7 +2,10 @@ package runn
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,6 +54,13 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			qm, ok := vvvvv["query"]
+			if ok {
+				req.query, err = parseHTTPQuery(qm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
 			bm, ok := vvvvv["body"]
 			if ok {
 				switch v := bm.(type) {
@@ -80,6 +90,76 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			fm, ok := vvvvv["followRedirect"]
+			if ok {
+				switch v := fm.(type) {
+				case bool:
+					req.followRedirect = &v
+				default:
+					if v != nil {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				}
+			}
+			mm, ok := vvvvv["maxRedirects"]
+			if ok {
+				var n int
+				switch v := mm.(type) {
+				case uint64:
+					n = int(v)
+				case int64:
+					n = int(v)
+				case int:
+					n = v
+				case float64:
+					n = int(v)
+				default:
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				if n < 0 {
+					return nil, fmt.Errorf("invalid request: %s: maxRedirects must be greater than or equal to 0", string(part))
+				}
+				req.maxRedirects = &n
+			}
+			tm, ok := vvvvv["timeout"]
+			if ok {
+				tms, ok := tm.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.timeout, err = duration.Parse(tms)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
+			gm, ok := vvvvv["graphql"]
+			if ok {
+				if req.body != nil {
+					return nil, fmt.Errorf("invalid request: %s: graphql and body cannot be used together", string(part))
+				}
+				req.graphql, err = parseHTTPGraphQL(gm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
+			cm, ok := vvvvv["compress"]
+			if ok {
+				enc, ok := cm.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				if !isSupportedContentEncoding(enc) {
+					return nil, fmt.Errorf("invalid request: %s: unsupported compress: %s", string(part), enc)
+				}
+				req.compress = enc
+			}
+			sm, ok := vvvvv["sse"]
+			if ok {
+				req.sse, err = parseHTTPSSE(sm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
 		}
 
 		break
@@ -90,6 +170,51 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 	return req, nil
 }
 
+func parseHTTPQuery(v any) (url.Values, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid query: %v", v)
+	}
+	q := url.Values{}
+	for k, vv := range m {
+		switch vvv := vv.(type) {
+		case []any:
+			for _, e := range vvv {
+				s, err := queryValue(e)
+				if err != nil {
+					return nil, fmt.Errorf("invalid query value of %s: %w", k, err)
+				}
+				q.Add(k, s)
+			}
+		default:
+			s, err := queryValue(vvv)
+			if err != nil {
+				return nil, fmt.Errorf("invalid query value of %s: %w", k, err)
+			}
+			q.Add(k, s)
+		}
+	}
+	return q, nil
+}
+
+func queryValue(v any) (string, error) {
+	switch vv := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return vv, nil
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64), nil
+	case map[string]any, []any:
+		return "", fmt.Errorf("%v", vv)
+	default:
+		return fmt.Sprintf("%v", vv), nil
+	}
+}
+
 func parseDBQuery(v map[string]any) (*dbQuery, error) {
 	q := &dbQuery{}
 	part, err := yaml.Marshal(v)
@@ -167,6 +292,17 @@ func parseGrpcRequest(v map[string]any, expand func(any) (any, error)) (*grpcReq
 				return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
 			}
 		}
+		wm, ok := vvv["waitForReady"]
+		if ok {
+			wme, err := expand(wm)
+			if err != nil {
+				return nil, err
+			}
+			req.waitForReady, ok = wme.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+		}
 		// `message:` and `messages:` expand at run time so not here
 		mm, ok := vvv["message"]
 		if ok {
@@ -234,6 +370,129 @@ func parseGrpcRequest(v map[string]any, expand func(any) (any, error)) (*grpcReq
 	return req, nil
 }
 
+func parseWSRequest(v map[string]any, expand func(any) (any, error)) (*wsRequest, error) {
+	v = trimDelimiter(v)
+	req := &wsRequest{
+		headers: http.Header{},
+	}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for k := range v {
+		switch k {
+		case "headers", "timeout", "messages":
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	hm, ok := v["headers"]
+	if ok {
+		hme, err := expand(hm)
+		if err != nil {
+			return nil, err
+		}
+		hm, ok := hme.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		for k, v := range hm {
+			switch vv := v.(type) {
+			case string:
+				req.headers.Add(k, vv)
+			case []any:
+				for _, vvv := range vv {
+					req.headers.Add(k, fmt.Sprintf("%v", vvv))
+				}
+			default:
+				req.headers.Add(k, fmt.Sprintf("%v", vv))
+			}
+		}
+	}
+	tm, ok := v["timeout"]
+	if ok {
+		tme, err := expand(tm)
+		if err != nil {
+			return nil, err
+		}
+		tms, ok := tme.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		req.timeout, err = duration.Parse(tms)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+		}
+	}
+	// The contents of `messages:` expand at run time so not here
+	mm, ok := v["messages"]
+	if !ok {
+		return req, nil
+	}
+	mms, ok := mm.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	for _, mm := range mms {
+		switch vv := mm.(type) {
+		case string:
+			e, err := expand(vv)
+			if err != nil {
+				return nil, err
+			}
+			op := WSOp(fmt.Sprintf("%v", e))
+			if op != WSOpReceive && op != WSOpClose {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.messages = append(req.messages, &wsMessage{
+				op: op,
+			})
+		case map[string]any:
+			if len(vv) != 1 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			for k, vvv := range vv {
+				switch k {
+				case string(WSMessageText), string(WSMessageJSON), string(WSMessageBinary):
+					req.messages = append(req.messages, &wsMessage{
+						op:     WSOpMessage,
+						typ:    WSMessageType(k),
+						params: vvv,
+					})
+				case string(WSOpReceive):
+					m := &wsMessage{
+						op: WSOpReceive,
+					}
+					ro, ok := vvv.(map[string]any)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					if tm, ok := ro["timeout"]; ok {
+						tme, err := expand(tm)
+						if err != nil {
+							return nil, err
+						}
+						tms, ok := tme.(string)
+						if !ok {
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+						m.timeout, err = duration.Parse(tms)
+						if err != nil {
+							return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+						}
+					}
+					req.messages = append(req.messages, m)
+				default:
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
 func parseCDPActions(v map[string]any, expand func(any) (any, error)) (CDPActions, error) {
 	v = trimDelimiter(v)
 	cas := CDPActions{}
@@ -2,6 +2,7 @@ package runn
 
 import (
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 func TestParseHTTPRequest(t *testing.T) {
 	use := true
 	notUse := false
+	maxRedirects := 3
 	tests := []struct {
 		in      string
 		want    *httpRequest
@@ -114,6 +116,229 @@ func TestParseHTTPRequest(t *testing.T) {
 			},
 			false,
 		},
+		{
+			`
+/users/k1LoW:
+  get:
+    query:
+      page: 2
+      q: "a&b=c"
+      tags:
+        - go
+        - ランタイム
+      empty: null
+    body: null
+`,
+			&httpRequest{
+				path:   "/users/k1LoW",
+				method: http.MethodGet,
+				query: url.Values{
+					"page":  []string{"2"},
+					"q":     []string{"a&b=c"},
+					"tags":  []string{"go", "ランタイム"},
+					"empty": []string{""},
+				},
+				mediaType: "",
+				headers:   map[string]string{},
+				body:      nil,
+			},
+			false,
+		},
+		{
+			`
+/users/k1LoW:
+  get:
+    query:
+      filter:
+        name: alice
+    body: null
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/login:
+  get:
+    body: null
+    followRedirect: true
+    maxRedirects: 3
+`,
+			&httpRequest{
+				path:           "/login",
+				method:         http.MethodGet,
+				mediaType:      "",
+				headers:        map[string]string{},
+				body:           nil,
+				followRedirect: &use,
+				maxRedirects:   &maxRedirects,
+			},
+			false,
+		},
+		{
+			`
+/login:
+  get:
+    body: null
+    maxRedirects: -1
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/users/k1LoW:
+  get:
+    body: null
+    timeout: 3sec
+`,
+			&httpRequest{
+				path:      "/users/k1LoW",
+				method:    http.MethodGet,
+				mediaType: "",
+				headers:   map[string]string{},
+				body:      nil,
+				timeout:   3 * time.Second,
+			},
+			false,
+		},
+		{
+			`
+/users/k1LoW:
+  get:
+    body: null
+    timeout: 3
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/events:
+  get:
+    body: null
+    sse:
+      count: 3
+      until: event.data.done == true
+      timeout: 10sec
+`,
+			&httpRequest{
+				path:      "/events",
+				method:    http.MethodGet,
+				mediaType: "",
+				headers:   map[string]string{},
+				body:      nil,
+				sse: &httpSSE{
+					Count:   3,
+					Until:   "event.data.done == true",
+					Timeout: "10sec",
+					timeout: 10 * time.Second,
+				},
+			},
+			false,
+		},
+		{
+			`
+/events:
+  get:
+    body: null
+    sse:
+      count: -1
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/events:
+  get:
+    body: null
+    sse:
+      timeout: 500
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      query: |
+        query GetUser($id: ID!) { user(id: $id) { name } }
+      variables:
+        id: 1
+      operationName: GetUser
+`,
+			&httpRequest{
+				path:    "/graphql",
+				method:  http.MethodPost,
+				headers: map[string]string{},
+				graphql: &httpGraphQL{
+					query:         "query GetUser($id: ID!) { user(id: $id) { name } }\n",
+					variables:     map[string]any{"id": uint64(1)},
+					operationName: "GetUser",
+				},
+			},
+			false,
+		},
+		{
+			`
+/graphql:
+  post:
+    body:
+      application/json:
+        key: value
+    graphql:
+      query: '{ users { name } }'
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      variables:
+        id: 1
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/upload:
+  post:
+    body:
+      application/json:
+        key: value
+    compress: gzip
+`,
+			&httpRequest{
+				path:      "/upload",
+				method:    http.MethodPost,
+				mediaType: MediaTypeApplicationJSON,
+				headers:   map[string]string{},
+				body: map[string]any{
+					"key": "value",
+				},
+				compress: "gzip",
+			},
+			false,
+		},
+		{
+			`
+/upload:
+  post:
+    body:
+      application/json:
+        key: value
+    compress: lzma
+`,
+			nil,
+			true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -131,7 +356,7 @@ func TestParseHTTPRequest(t *testing.T) {
 		if tt.wantErr {
 			t.Error("want error")
 		}
-		opts := cmp.AllowUnexported(httpRequest{})
+		opts := cmp.AllowUnexported(httpRequest{}, httpSSE{}, httpGraphQL{})
 		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
 			t.Error(diff)
 		}
@@ -319,13 +544,46 @@ my.custom.server.Service/Method:
 			},
 			false,
 		},
+		{
+			`
+my.custom.server.Service/Method:
+  waitForReady: true
+  message:
+    key: value
+`,
+			&grpcRequest{
+				service: "my.custom.server.Service",
+				method:  "Method",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"key": "value",
+						},
+					},
+				},
+				waitForReady: true,
+			},
+			false,
+		},
+		{
+			`
+my.custom.server.Service/Method:
+  waitForReady: "{{ vars.wait }}"
+  message:
+    key: value
+`,
+			nil,
+			true,
+		},
 	}
 
 	o, err := New()
 	if err != nil {
 		t.Fatal(err)
 	}
-	o.store.vars = map[string]any{"path": "my.custom.server.Service/Method", "one": "ichi", "two": "ni"}
+	o.store.vars = map[string]any{"path": "my.custom.server.Service/Method", "one": "ichi", "two": "ni", "wait": "yes"}
 
 	for _, tt := range tests {
 		var v map[string]any
@@ -349,6 +607,106 @@ my.custom.server.Service/Method:
 	}
 }
 
+func TestParseWSRequest(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *wsRequest
+		wantErr bool
+	}{
+		{
+			`
+messages:
+  - text: hello
+  - receive
+`,
+			&wsRequest{
+				headers: http.Header{},
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageText, params: "hello"},
+					{op: WSOpReceive},
+				},
+			},
+			false,
+		},
+		{
+			`
+headers:
+  authorization: "Bearer {{ vars.token }}"
+timeout: 10sec
+messages:
+  - json:
+      key: value
+  - binary: aGVsbG8=
+  - receive:
+      timeout: 3sec
+  - close
+`,
+			&wsRequest{
+				headers: http.Header{"Authorization": []string{"Bearer xxxxx"}},
+				timeout: 10 * time.Second,
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageJSON, params: map[string]any{"key": "value"}},
+					{op: WSOpMessage, typ: WSMessageBinary, params: "aGVsbG8="},
+					{op: WSOpReceive, timeout: 3 * time.Second},
+					{op: WSOpClose},
+				},
+			},
+			false,
+		},
+		{
+			`
+messages:
+  - send
+`,
+			nil,
+			true,
+		},
+		{
+			`
+messages:
+  - text: hello
+    json: hello
+`,
+			nil,
+			true,
+		},
+		{
+			`
+path: /chat
+`,
+			nil,
+			true,
+		},
+	}
+
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.store.vars = map[string]any{"token": "xxxxx"}
+
+	for _, tt := range tests {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseWSRequest(v, o.expandBeforeRecord)
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		opts := cmp.AllowUnexported(wsRequest{}, wsMessage{})
+		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
 func TestParseExecCommand(t *testing.T) {
 	tests := []struct {
 		in      string
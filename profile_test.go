@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/goccy/go-json"
@@ -87,3 +90,54 @@ func calcDepth(s *stopw.Span) int {
 	}
 	return d
 }
+
+func TestProfileHTTPTimings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	p := filepath.Join(t.TempDir(), "book.yml")
+	rb := `desc: HTTP timings
+steps:
+  -
+    req:
+      /:
+        get:
+          body: null
+    test: current.res.status == 200
+`
+	if err := os.WriteFile(p, []byte(rb), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(T(t), Book(p), Profile(true), Runner("req", ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := o.DumpProfile(buf); err != nil {
+		t.Fatal(err)
+	}
+	var s *stopw.Span
+	if err := json.Unmarshal(buf.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	var walk func(s *stopw.Span)
+	walk = func(s *stopw.Span) {
+		if id, ok := s.ID.(map[string]any); ok && id["type"] == string(TrailTypeHTTPTiming) {
+			got[id["desc"].(string)] = true
+		}
+		for _, b := range s.Breakdown {
+			walk(b)
+		}
+	}
+	walk(s)
+	for _, want := range []string{httpTimingConnect, httpTimingTTFB, httpTimingTransfer} {
+		if !got[want] {
+			t.Errorf("%s is not recorded: %v", want, got)
+		}
+	}
+}
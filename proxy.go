@@ -0,0 +1,204 @@
+package runn
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+type runnerProxy struct {
+	url     *url.URL
+	noProxy []string
+}
+
+// newRunnerProxy returns proxy settings of the runner using proxy URL (http://, https://, socks5://) and comma-separated no proxy hosts.
+func newRunnerProxy(proxyURL, noProxy string) (*runnerProxy, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy: unsupported scheme: %s", proxyURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy: host is required: %s", proxyURL)
+	}
+	p := &runnerProxy{url: u}
+	for _, h := range strings.Split(noProxy, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		p.noProxy = append(p.noProxy, h)
+	}
+	return p, nil
+}
+
+// useProxy returns whether the proxy should be used for the addr (host or host:port).
+func (p *runnerProxy) useProxy(addr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+		port = ""
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	ip := net.ParseIP(host)
+	for _, np := range p.noProxy {
+		if np == "*" {
+			return false
+		}
+		if _, cidr, err := net.ParseCIDR(np); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return false
+			}
+			continue
+		}
+		npHost, npPort, err := net.SplitHostPort(np)
+		if err != nil {
+			npHost = np
+			npPort = ""
+		}
+		npHost = strings.Trim(npHost, "[]")
+		if npPort != "" && npPort != port {
+			continue
+		}
+		if host == strings.TrimPrefix(npHost, ".") {
+			return false
+		}
+		if strings.HasSuffix(host, "."+strings.TrimPrefix(npHost, ".")) {
+			return false
+		}
+	}
+	return true
+}
+
+// httpProxyFunc returns the function for http.Transport.Proxy.
+func (p *runnerProxy) httpProxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if !p.useProxy(req.URL.Host) {
+			return nil, nil
+		}
+		return p.url, nil
+	}
+}
+
+// dialContext dials addr via the proxy. http:// and https:// proxies are used with CONNECT method.
+func (p *runnerProxy) dialContext(ctx context.Context, addr string) (net.Conn, error) {
+	d := &net.Dialer{}
+	if !p.useProxy(addr) {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	switch p.url.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if p.url.User != nil {
+			pass, _ := p.url.User.Password()
+			auth = &proxy.Auth{
+				User:     p.url.User.Username(),
+				Password: pass,
+			}
+		}
+		sd, err := proxy.SOCKS5("tcp", p.url.Host, auth, d)
+		if err != nil {
+			return nil, err
+		}
+		cd, ok := sd.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("invalid socks5 dialer: %#v", sd)
+		}
+		return cd.DialContext(ctx, "tcp", addr)
+	default:
+		conn, err := d.DialContext(ctx, "tcp", p.proxyAddr())
+		if err != nil {
+			return nil, err
+		}
+		if p.url.Scheme == "https" {
+			tc := tls.Client(conn, &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				ServerName: p.url.Hostname(),
+			})
+			if err := tc.HandshakeContext(ctx); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			conn = tc
+		}
+		pc, err := p.connect(ctx, conn, addr)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return pc, nil
+	}
+}
+
+func (p *runnerProxy) connect(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if p.url.User != nil {
+		pass, _ := p.url.User.Password()
+		cred := base64.StdEncoding.EncodeToString([]byte(p.url.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+cred)
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+		defer func() {
+			_ = conn.SetDeadline(time.Time{})
+		}()
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("proxy CONNECT %s failed: %s", addr, res.Status)
+	}
+	// Do not read the body of the successful response. The bytes after the header belong to the tunnel.
+	if br.Buffered() > 0 {
+		// The server may send data (e.g. HTTP/2 SETTINGS frame) right after the tunnel is established.
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (p *runnerProxy) proxyAddr() string {
+	if p.url.Port() != "" {
+		return p.url.Host
+	}
+	if p.url.Scheme == "https" {
+		return net.JoinHostPort(p.url.Hostname(), "443")
+	}
+	return net.JoinHostPort(p.url.Hostname(), "80")
+}
@@ -0,0 +1,68 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// hostResolver overrides the address to connect to for the host (like `curl --resolve`).
+// Since only the address to dial is replaced, the Host header and SNI are kept intact.
+type hostResolver struct {
+	hosts map[string]string
+}
+
+// newHostResolver returns the resolver using the map of `host:port` (or `host` for any port) to the address.
+func newHostResolver(m map[string]string) (*hostResolver, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	r := &hostResolver{hosts: map[string]string{}}
+	for k, v := range m {
+		if strings.TrimSpace(k) == "" || strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("invalid resolve: %q: %q", k, v)
+		}
+		if h, _, err := net.SplitHostPort(k); err == nil {
+			if h == "" {
+				return nil, fmt.Errorf("invalid resolve: host is required: %q", k)
+			}
+		}
+		r.hosts[strings.ToLower(k)] = v
+	}
+	return r, nil
+}
+
+// resolve returns the address to connect to for addr (host:port).
+func (r *hostResolver) resolve(addr string) string {
+	if r == nil {
+		return addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	host = strings.ToLower(host)
+	to, ok := r.hosts[net.JoinHostPort(host, port)]
+	if !ok {
+		to, ok = r.hosts[host]
+		if !ok {
+			return addr
+		}
+	}
+	if _, _, err := net.SplitHostPort(to); err == nil {
+		// The port is also overridden
+		return to
+	}
+	return net.JoinHostPort(strings.Trim(to, "[]"), port)
+}
+
+// dialContext dials the overridden address of addr.
+func (r *hostResolver) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return d.DialContext(ctx, network, r.resolve(addr))
+}
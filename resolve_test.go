@@ -0,0 +1,168 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestHostResolverResolve(t *testing.T) {
+	hosts := map[string]string{
+		"api.example.com:443": "10.0.0.1",
+		"api.example.com":     "10.0.0.2",
+		"grpc.example.com":    "10.0.0.3:50051",
+		"v6.example.com":      "[::1]",
+	}
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"api.example.com:443", "10.0.0.1:443"},
+		{"API.example.com:443", "10.0.0.1:443"},
+		{"api.example.com:8080", "10.0.0.2:8080"},
+		{"grpc.example.com:443", "10.0.0.3:50051"},
+		{"v6.example.com:443", "[::1]:443"},
+		{"example.com:443", "example.com:443"},
+		{"api.example.com", "api.example.com"},
+	}
+	r, err := newHostResolver(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := r.resolve(tt.addr); got != tt.want {
+			t.Errorf("%s: got %v\nwant %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestNewHostResolver(t *testing.T) {
+	tests := []struct {
+		in      map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{nil, true, false},
+		{map[string]string{"api.example.com:443": "127.0.0.1"}, false, false},
+		{map[string]string{"api.example.com:443": ""}, false, true},
+		{map[string]string{":443": "127.0.0.1"}, false, true},
+	}
+	for _, tt := range tests {
+		got, err := newHostResolver(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%v: %v", tt.in, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("%v: want error", tt.in)
+			continue
+		}
+		if (got == nil) != tt.wantNil {
+			t.Errorf("%v: got %v\nwant nil: %v", tt.in, got, tt.wantNil)
+		}
+	}
+}
+
+func TestHTTPRunnerResolve(t *testing.T) {
+	var (
+		gotHost string
+		gotSNI  string
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		gotSNI = r.TLS.ServerName
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.StartTLS()
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunner("req", "https://api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.skipVerify = true
+	addr := strings.TrimPrefix(ts.URL, "https://")
+	if err := r.setConnectionOptions(&httpRunnerConfig{Resolve: map[string]string{"api.example.com:443": addr}}); err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{
+		path:    "/users",
+		method:  http.MethodGet,
+		headers: map[string]string{},
+	}
+	if err := r.Run(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if want := "api.example.com"; gotHost != want {
+		t.Errorf("got %v\nwant %v", gotHost, want)
+	}
+	if want := "api.example.com"; gotSNI != want {
+		t.Errorf("got %v\nwant %v", gotSNI, want)
+	}
+}
+
+func TestHTTPRunnerResolveWithProxy(t *testing.T) {
+	var (
+		gotHost string
+		gotSNI  string
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		gotSNI = r.TLS.ServerName
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.StartTLS()
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ps := testutil.HTTPProxyServer(t, "", "")
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunner("req", "https://api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	r.skipVerify = true
+	addr := strings.TrimPrefix(ts.URL, "https://")
+	if err := r.setConnectionOptions(&httpRunnerConfig{
+		Proxy:   ps.URL,
+		Resolve: map[string]string{"api.example.com:443": addr},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{
+		path:    "/users",
+		method:  http.MethodGet,
+		headers: map[string]string{},
+	}
+	if err := r.Run(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(ps.Requests(), []string{addr}); diff != "" {
+		t.Error(diff)
+	}
+	if want := "api.example.com"; gotHost != want {
+		t.Errorf("got %v\nwant %v", gotHost, want)
+	}
+	if want := "api.example.com"; gotSNI != want {
+		t.Errorf("got %v\nwant %v", gotSNI, want)
+	}
+}
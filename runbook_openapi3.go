@@ -0,0 +1,407 @@
+package runn
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	openAPI3DefaultEndpoint = "http://localhost:8080"
+	openAPI3DefaultTag      = "default"
+	openAPI3MaxSchemaDepth  = 8
+	openAPI3RunnerKey       = "req"
+)
+
+var openAPI3PathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+var openAPI3Methods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// NewRunbooksFromOpenAPI3 creates runbooks from the OpenAPI 3 document at location.
+// It creates one runbook per operation, or one runbook per tag if byTag is true.
+// ref is set to `openapi3:` of the HTTP runner ( if empty, location is used ).
+// The keys of the returned map are the names of the runbooks.
+func NewRunbooksFromOpenAPI3(location, ref string, byTag bool) (map[string]*runbook, error) {
+	doc, err := loadOpenAPI3Doc(location)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = location
+	}
+	runner := yaml.MapSlice{
+		{Key: "endpoint", Value: openAPI3Endpoint(doc)},
+		{Key: "openapi3", Value: ref},
+	}
+
+	rbs := map[string]*runbook{}
+	var paths []string
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		pi := doc.Paths[p]
+		for _, m := range openAPI3Methods {
+			op := pi.GetOperation(m)
+			if op == nil {
+				continue
+			}
+			var name, desc string
+			switch {
+			case byTag:
+				tag := openAPI3DefaultTag
+				if len(op.Tags) > 0 {
+					tag = op.Tags[0]
+				}
+				name = openAPI3RunbookName(tag)
+				desc = tag
+				if t := doc.Tags.Get(tag); t != nil && t.Description != "" {
+					desc = t.Description
+				}
+			default:
+				name = openAPI3OperationName(m, p, op)
+				desc = fmt.Sprintf("%s %s", m, p)
+				switch {
+				case op.Summary != "":
+					desc = op.Summary
+				case op.OperationID != "":
+					desc = op.OperationID
+				}
+			}
+			rb, ok := rbs[name]
+			if !ok {
+				rb = NewRunbook(desc)
+				rb.Runners[openAPI3RunnerKey] = runner
+				rbs[name] = rb
+			}
+			rb.appendOpenAPI3Step(m, p, pi, op, byTag)
+		}
+	}
+	return rbs, nil
+}
+
+func (rb *runbook) appendOpenAPI3Step(method, path string, pi *openapi3.PathItem, op *openapi3.Operation, withDesc bool) {
+	// parameters of the operation override parameters of the path item
+	params := map[string]*openapi3.Parameter{}
+	var keys []string
+	for _, ps := range []openapi3.Parameters{pi.Parameters, op.Parameters} {
+		for _, pr := range ps {
+			if pr == nil || pr.Value == nil {
+				continue
+			}
+			k := fmt.Sprintf("%s:%s", pr.Value.In, pr.Value.Name)
+			if _, ok := params[k]; !ok {
+				keys = append(keys, k)
+			}
+			params[k] = pr.Value
+		}
+	}
+
+	endpoint := openAPI3PathParamRe.ReplaceAllStringFunc(path, func(s string) string {
+		n := strings.Trim(s, "{}")
+		return fmt.Sprintf("{{ vars.%s }}", openAPI3VarName(n))
+	})
+	var query []string
+	headers := yaml.MapSlice{}
+	for _, k := range keys {
+		pr := params[k]
+		vn := openAPI3VarName(pr.Name)
+		switch {
+		case pr.In == openapi3.ParameterInPath:
+		case pr.In == openapi3.ParameterInQuery && pr.Required:
+			query = append(query, fmt.Sprintf("%s={{ vars.%s }}", url.QueryEscape(pr.Name), vn))
+		case pr.In == openapi3.ParameterInHeader && pr.Required && !openAPI3IgnoredHeader(pr.Name):
+			headers = append(headers, yaml.MapItem{Key: pr.Name, Value: fmt.Sprintf("{{ vars.%s }}", vn)})
+		default:
+			continue
+		}
+		if _, ok := rb.Vars[vn]; !ok {
+			rb.Vars[vn] = openAPI3ParameterExample(pr)
+		}
+	}
+	if len(query) > 0 {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, strings.Join(query, "&"))
+	}
+
+	var bd yaml.MapSlice
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		if ct, mt, ok := openAPI3RequestMediaType(op.RequestBody.Value.Content); ok {
+			v := openAPI3MediaTypeExample(mt)
+			if ct == MediaTypeTextPlain {
+				v = fmt.Sprintf("%v", v)
+			}
+			bd = yaml.MapSlice{
+				{Key: ct, Value: v},
+			}
+		}
+	}
+	hb := yaml.MapSlice{}
+	if len(headers) > 0 {
+		hb = append(hb, yaml.MapItem{
+			Key:   "headers",
+			Value: headers,
+		})
+	}
+	if len(bd) == 0 {
+		hb = append(hb, yaml.MapItem{
+			Key:   "body",
+			Value: nil,
+		})
+	} else {
+		hb = append(hb, yaml.MapItem{
+			Key:   "body",
+			Value: bd,
+		})
+	}
+
+	step := yaml.MapSlice{}
+	if withDesc {
+		desc := fmt.Sprintf("%s %s", method, path)
+		switch {
+		case op.Summary != "":
+			desc = op.Summary
+		case op.OperationID != "":
+			desc = op.OperationID
+		}
+		step = append(step, yaml.MapItem{Key: "desc", Value: desc})
+	}
+	step = append(step, yaml.MapItem{Key: openAPI3RunnerKey, Value: yaml.MapSlice{
+		{Key: endpoint, Value: yaml.MapSlice{
+			{Key: strings.ToLower(method), Value: hb},
+		}},
+	}})
+	if cond := openAPI3SuccessCond(op.Responses); cond != "" {
+		step = append(step, yaml.MapItem{Key: "test", Value: cond})
+	}
+	rb.Steps = append(rb.Steps, step)
+}
+
+// openAPI3Endpoint returns the URL of the first server of the document.
+func openAPI3Endpoint(doc *openapi3.T) string {
+	if len(doc.Servers) == 0 || doc.Servers[0] == nil {
+		return openAPI3DefaultEndpoint
+	}
+	s := doc.Servers[0]
+	u := s.URL
+	for k, v := range s.Variables {
+		if v == nil {
+			continue
+		}
+		u = strings.ReplaceAll(u, fmt.Sprintf("{%s}", k), v.Default)
+	}
+	if strings.HasPrefix(u, "/") {
+		// relative URL
+		return openAPI3DefaultEndpoint + strings.TrimSuffix(u, "/")
+	}
+	return u
+}
+
+// openAPI3OperationName returns the name of the runbook of the operation.
+func openAPI3OperationName(method, path string, op *openapi3.Operation) string {
+	if op.OperationID != "" {
+		return openAPI3RunbookName(op.OperationID)
+	}
+	return openAPI3RunbookName(fmt.Sprintf("%s %s", strings.ToLower(method), path))
+}
+
+// openAPI3RunbookName returns the name that can be used as a file name.
+func openAPI3RunbookName(in string) string {
+	return strings.Join(strings.FieldsFunc(in, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}), "_")
+}
+
+// openAPI3VarName returns the name of the variable for the parameter.
+// The name is converted to the identifier that can be used in expressions ( e.g. `user-id` -> `user_id`, `page[size]` -> `page_size` ).
+func openAPI3VarName(name string) string {
+	n := strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+	}), "_")
+	if n == "" || (n[0] >= '0' && n[0] <= '9') {
+		n = "_" + n
+	}
+	return n
+}
+
+// openAPI3IgnoredHeader returns whether the header parameter is ignored by OpenAPI 3 ( Accept, Content-Type and Authorization ).
+func openAPI3IgnoredHeader(name string) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "Accept", "Content-Type", "Authorization":
+		return true
+	}
+	return false
+}
+
+// openAPI3RequestMediaType returns the media type of the request body supported by `runn new`.
+func openAPI3RequestMediaType(content openapi3.Content) (string, *openapi3.MediaType, bool) {
+	var cts []string
+	for ct := range content {
+		cts = append(cts, ct)
+	}
+	sort.Strings(cts)
+	for _, fn := range []func(string) bool{
+		func(ct string) bool { return ct == MediaTypeApplicationJSON },
+		func(ct string) bool { return strings.HasSuffix(ct, "+json") },
+		func(ct string) bool { return ct == MediaTypeApplicationFormUrlencoded },
+		func(ct string) bool { return ct == MediaTypeTextPlain },
+	} {
+		for _, ct := range cts {
+			if fn(ct) {
+				return ct, content[ct], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// openAPI3SuccessCond returns the test condition that checks the documented success status.
+func openAPI3SuccessCond(responses openapi3.Responses) string {
+	var codes []int
+	for s := range responses {
+		c, err := strconv.Atoi(s)
+		if err != nil {
+			continue
+		}
+		if c >= 200 && c < 300 {
+			codes = append(codes, c)
+		}
+	}
+	if len(codes) > 0 {
+		sort.Ints(codes)
+		return fmt.Sprintf("current.res.status == %d", codes[0])
+	}
+	if _, ok := responses["2XX"]; ok {
+		return "current.res.status >= 200 && current.res.status < 300"
+	}
+	return ""
+}
+
+func openAPI3ParameterExample(pr *openapi3.Parameter) any {
+	if pr.Example != nil {
+		return pr.Example
+	}
+	if v, ok := openAPI3ExamplesValue(pr.Examples); ok {
+		return v
+	}
+	return openAPI3SchemaExample(pr.Schema, 0)
+}
+
+func openAPI3MediaTypeExample(mt *openapi3.MediaType) any {
+	if mt == nil {
+		return nil
+	}
+	if mt.Example != nil {
+		return mt.Example
+	}
+	if v, ok := openAPI3ExamplesValue(mt.Examples); ok {
+		return v
+	}
+	return openAPI3SchemaExample(mt.Schema, 0)
+}
+
+func openAPI3ExamplesValue(examples openapi3.Examples) (any, bool) {
+	var keys []string
+	for k := range examples {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e := examples[k]
+		if e != nil && e.Value != nil && e.Value.Value != nil {
+			return e.Value.Value, true
+		}
+	}
+	return nil, false
+}
+
+// openAPI3SchemaExample synthesizes the example value from the schema.
+func openAPI3SchemaExample(sr *openapi3.SchemaRef, depth int) any {
+	if sr == nil || sr.Value == nil || depth > openAPI3MaxSchemaDepth {
+		return nil
+	}
+	s := sr.Value
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		m := map[string]any{}
+		for _, ss := range s.AllOf {
+			if v, ok := openAPI3SchemaExample(ss, depth+1).(map[string]any); ok {
+				for k, vv := range v {
+					m[k] = vv
+				}
+			}
+		}
+		for k, v := range openAPI3PropertiesExample(s, depth) {
+			m[k] = v
+		}
+		return m
+	case len(s.OneOf) > 0:
+		return openAPI3SchemaExample(s.OneOf[0], depth+1)
+	case len(s.AnyOf) > 0:
+		return openAPI3SchemaExample(s.AnyOf[0], depth+1)
+	}
+	switch s.Type {
+	case openapi3.TypeObject:
+		return openAPI3PropertiesExample(s, depth)
+	case openapi3.TypeArray:
+		return []any{openAPI3SchemaExample(s.Items, depth+1)}
+	case openapi3.TypeString:
+		switch s.Format {
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "date":
+			return "2006-01-02"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	case openapi3.TypeInteger, openapi3.TypeNumber:
+		if s.Min != nil {
+			return *s.Min
+		}
+		return 0
+	case openapi3.TypeBoolean:
+		return false
+	}
+	if len(s.Properties) > 0 {
+		return openAPI3PropertiesExample(s, depth)
+	}
+	return nil
+}
+
+func openAPI3PropertiesExample(s *openapi3.Schema, depth int) map[string]any {
+	m := map[string]any{}
+	for k, p := range s.Properties {
+		if p != nil && p.Value != nil && p.Value.ReadOnly {
+			continue
+		}
+		m[k] = openAPI3SchemaExample(p, depth+1)
+	}
+	return m
+}
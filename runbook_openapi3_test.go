@@ -0,0 +1,139 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/tenntenn/golden"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNewRunbooksFromOpenAPI3(t *testing.T) {
+	tests := []struct {
+		name  string
+		byTag bool
+	}{
+		{"openapi3_new_per_operation", false},
+		{"openapi3_new_per_tag", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rbs, err := NewRunbooksFromOpenAPI3("testdata/openapi3_new.yml", "openapi3_new.yml", tt.byTag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for n := range rbs {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+
+			got := new(bytes.Buffer)
+			for _, n := range names {
+				_, _ = fmt.Fprintf(got, "# %s\n", n)
+				enc := yaml.NewEncoder(got)
+				if err := enc.Encode(rbs[n]); err != nil {
+					t.Error(err)
+				}
+			}
+
+			f := fmt.Sprintf("%s.runbook", tt.name)
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", f, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", f, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNewRunbooksFromOpenAPI3Load(t *testing.T) {
+	ref, err := filepath.Abs("testdata/openapi3.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbs, err := NewRunbooksFromOpenAPI3("testdata/openapi3.yml", ref, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for n, rb := range rbs {
+		t.Run(n, func(t *testing.T) {
+			b, err := yaml.Marshal(rb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := filepath.Join(dir, fmt.Sprintf("%s.yml", n))
+			if err := os.WriteFile(p, b, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := New(Book(p)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNewRunbooksFromOpenAPI3Run(t *testing.T) {
+	spec, err := os.ReadFile("testdata/openapi3_new.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	// The HTTP validator does not expand server variables
+	ref := filepath.Join(dir, "openapi3_new.yml")
+	spec = bytes.Replace(spec, []byte(`  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: api
+`), []byte("  - url: https://api.example.com/v1\n"), 1)
+	if err := os.WriteFile(ref, spec, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	rbs, err := NewRunbooksFromOpenAPI3(ref, ref, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	b, err := yaml.Marshal(rbs["listUserPosts"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, "listUserPosts.yml")
+	if err := os.WriteFile(p, b, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Book(p), Runner("req", ts.URL+"/v1", OpenApi3(ref)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("no request")
+	}
+	if want := "/v1/users/1/posts"; got.URL.Path != want {
+		t.Errorf("got %v\nwant %v", got.URL.Path, want)
+	}
+	if want := "10"; got.URL.Query().Get("page[size]") != want {
+		t.Errorf("got %v\nwant %v", got.URL.Query().Get("page[size]"), want)
+	}
+	if got.Header.Get("X-Request-Id") == "" {
+		t.Error("X-Request-Id header is not set")
+	}
+}
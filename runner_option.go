@@ -1,38 +1,155 @@
 package runn
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
 type httpRunnerConfig struct {
-	Endpoint             string `yaml:"endpoint"`
-	OpenApi3DocLocation  string `yaml:"openapi3,omitempty"`
-	SkipValidateRequest  bool   `yaml:"skipValidateRequest,omitempty"`
-	SkipValidateResponse bool   `yaml:"skipValidateResponse,omitempty"`
-	NotFollowRedirect    bool   `yaml:"notFollowRedirect,omitempty"`
-	MultipartBoundary    string `yaml:"multipartBoundary,omitempty"`
-	CACert               string `yaml:"cacert,omitempty"`
-	Cert                 string `yaml:"cert,omitempty"`
-	Key                  string `yaml:"key,omitempty"`
-	SkipVerify           bool   `yaml:"skipVerify,omitempty"`
-	Timeout              string `yaml:"timeout,omitempty"`
-	UseCookie            *bool  `yaml:"useCookie,omitempty"`
+	Endpoint             string            `yaml:"endpoint"`
+	OpenApi3DocLocation  string            `yaml:"openapi3,omitempty"`
+	Swagger2DocLocation  string            `yaml:"swagger2,omitempty"`
+	SkipValidateRequest  bool              `yaml:"skipValidateRequest,omitempty"`
+	SkipValidateResponse bool              `yaml:"skipValidateResponse,omitempty"`
+	Validators           []string          `yaml:"validators,omitempty"`
+	FailOnGraphQLErrors  bool              `yaml:"failOnGraphQLErrors,omitempty"`
+	NotFollowRedirect    bool              `yaml:"notFollowRedirect,omitempty"`
+	MultipartBoundary    string            `yaml:"multipartBoundary,omitempty"`
+	CACert               string            `yaml:"cacert,omitempty"`
+	Cert                 string            `yaml:"cert,omitempty"`
+	Key                  string            `yaml:"key,omitempty"`
+	SkipVerify           bool              `yaml:"skipVerify,omitempty"`
+	Timeout              string            `yaml:"timeout,omitempty"`
+	UseCookie            *bool             `yaml:"useCookie,omitempty"`
+	IdleConnTimeout      string            `yaml:"idleConnTimeout,omitempty"`
+	MaxIdleConns         int               `yaml:"maxIdleConns,omitempty"`
+	DisableKeepAlives    bool              `yaml:"disableKeepAlives,omitempty"`
+	Retry                *httpRetryConfig  `yaml:"retry,omitempty"`
+	Proxy                string            `yaml:"proxy,omitempty"`
+	NoProxy              string            `yaml:"noProxy,omitempty"`
+	Resolve              map[string]string `yaml:"resolve,omitempty"`
+	Auth                 *httpAuthConfig   `yaml:"auth,omitempty"`
 
 	openApi3Doc *openapi3.T
 }
 
+type httpRetryConfig struct {
+	Count       int    `yaml:"count,omitempty"`
+	MinInterval string `yaml:"minInterval,omitempty"`
+	MaxInterval string `yaml:"maxInterval,omitempty"`
+}
+
+type httpAuthConfig struct {
+	Type string `yaml:"type"`
+	// basic, digest and oauth2 ( password grant )
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// bearer
+	Token string `yaml:"token,omitempty"`
+	// oauth2
+	TokenURL     string   `yaml:"tokenURL,omitempty"`
+	GrantType    string   `yaml:"grantType,omitempty"`
+	ClientID     string   `yaml:"clientID,omitempty"`
+	ClientSecret string   `yaml:"clientSecret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	// sigv4
+	Region          string `yaml:"region,omitempty"`
+	Service         string `yaml:"service,omitempty"`
+	AccessKeyID     string `yaml:"accessKeyID,omitempty"`
+	SecretAccessKey string `yaml:"secretAccessKey,omitempty"`
+	SessionToken    string `yaml:"sessionToken,omitempty"`
+}
+
 type grpcRunnerConfig struct {
-	Addr        string   `yaml:"addr"`
-	TLS         *bool    `yaml:"tls,omitempty"`
-	CACert      string   `yaml:"cacert,omitempty"`
-	Cert        string   `yaml:"cert,omitempty"`
-	Key         string   `yaml:"key,omitempty"`
-	SkipVerify  bool     `yaml:"skipVerify,omitempty"`
-	ImportPaths []string `yaml:"importPaths,omitempty"`
-	Protos      []string `yaml:"protos,omitempty"`
+	Addr        string            `yaml:"addr"`
+	TLS         *bool             `yaml:"tls,omitempty"`
+	CACert      string            `yaml:"cacert,omitempty"`
+	Cert        string            `yaml:"cert,omitempty"`
+	Key         string            `yaml:"key,omitempty"`
+	SkipVerify  bool              `yaml:"skipVerify,omitempty"`
+	ImportPaths []string          `yaml:"importPaths,omitempty"`
+	Protos      []string          `yaml:"protos,omitempty"`
+	Proxy       string            `yaml:"proxy,omitempty"`
+	NoProxy     string            `yaml:"noProxy,omitempty"`
+	Resolve     map[string]string `yaml:"resolve,omitempty"`
+	Protocol    string            `yaml:"protocol,omitempty"`
+	Codec       string            `yaml:"codec,omitempty"`
+	Protosets   []string          `yaml:"protosets,omitempty"`
+	BufImage    string            `yaml:"bufImage,omitempty"`
+
+	// dial options
+	Compression    string                 `yaml:"compression,omitempty"`
+	Keepalive      *grpcKeepaliveConfig   `yaml:"keepalive,omitempty"`
+	MaxRecvMsgSize int                    `yaml:"maxRecvMsgSize,omitempty"`
+	MaxSendMsgSize int                    `yaml:"maxSendMsgSize,omitempty"`
+	RetryPolicy    *grpcRetryPolicyConfig `yaml:"retryPolicy,omitempty"`
+	Authority      string                 `yaml:"authority,omitempty"`
+	ServerName     string                 `yaml:"serverName,omitempty"`
+	Credentials    *grpcCredentialsConfig `yaml:"credentials,omitempty"`
+
+	cacert []byte
+	cert   []byte
+	key    []byte
+}
+
+type grpcKeepaliveConfig struct {
+	Time                string `yaml:"time,omitempty"`
+	Timeout             string `yaml:"timeout,omitempty"`
+	PermitWithoutStream bool   `yaml:"permitWithoutStream,omitempty"`
+}
+
+// grpcCredentialsConfig is the config of per-RPC credentials. Only one of them can be set.
+type grpcCredentialsConfig struct {
+	Bearer *grpcBearerConfig `yaml:"bearer,omitempty"`
+	OAuth2 *grpcOAuth2Config `yaml:"oauth2,omitempty"`
+	JWT    *grpcJWTConfig    `yaml:"jwt,omitempty"`
+	// Insecure allows sending credentials over the connection without TLS
+	Insecure bool `yaml:"insecure,omitempty"`
+}
+
+type grpcBearerConfig struct {
+	Token string `yaml:"token"`
+}
+
+type grpcOAuth2Config struct {
+	TokenURL       string            `yaml:"tokenURL"`
+	ClientID       string            `yaml:"clientID"`
+	ClientSecret   string            `yaml:"clientSecret"`
+	Scopes         []string          `yaml:"scopes,omitempty"`
+	EndpointParams map[string]string `yaml:"endpointParams,omitempty"`
+}
+
+type grpcJWTConfig struct {
+	Key       string         `yaml:"key"`
+	Algorithm string         `yaml:"algorithm,omitempty"`
+	KeyID     string         `yaml:"keyID,omitempty"`
+	Issuer    string         `yaml:"issuer,omitempty"`
+	Subject   string         `yaml:"subject,omitempty"`
+	Audience  []string       `yaml:"audience,omitempty"`
+	Claims    map[string]any `yaml:"claims,omitempty"`
+	TTL       string         `yaml:"ttl,omitempty"`
+
+	key []byte
+}
+
+type grpcRetryPolicyConfig struct {
+	MaxAttempts          int      `yaml:"maxAttempts"`
+	InitialBackoff       string   `yaml:"initialBackoff,omitempty"`
+	MaxBackoff           string   `yaml:"maxBackoff,omitempty"`
+	BackoffMultiplier    float64  `yaml:"backoffMultiplier,omitempty"`
+	RetryableStatusCodes []string `yaml:"retryableStatusCodes,omitempty"`
+}
+
+type wsRunnerConfig struct {
+	Endpoint     string            `yaml:"endpoint"`
+	Headers      map[string]string `yaml:"headers,omitempty"`
+	Subprotocols []string          `yaml:"subprotocols,omitempty"`
+	CACert       string            `yaml:"cacert,omitempty"`
+	Cert         string            `yaml:"cert,omitempty"`
+	Key          string            `yaml:"key,omitempty"`
+	SkipVerify   bool              `yaml:"skipVerify,omitempty"`
+	Timeout      string            `yaml:"timeout,omitempty"`
 
 	cacert []byte
 	cert   []byte
@@ -61,6 +178,8 @@ type httpRunnerOption func(*httpRunnerConfig) error
 
 type grpcRunnerOption func(*grpcRunnerConfig) error
 
+type wsRunnerOption func(*wsRunnerConfig) error
+
 type sshRunnerOption func(*sshRunnerConfig) error
 
 func (c *sshRunnerConfig) validate() error {
@@ -81,17 +200,32 @@ func OpenApi3(l string) httpRunnerOption {
 	}
 }
 
-// OpenApi3FromData sets OpenAPI Document from data.
+// OpenApi3FromData sets OpenAPI Document ( 3.0 or 3.1 ) from data.
 func OpenApi3FromData(d []byte) httpRunnerOption {
 	return func(c *httpRunnerConfig) error {
-		ctx := context.Background()
-		loader := openapi3.NewLoader()
-		doc, err := loader.LoadFromData(d)
+		doc, err := parseOpenAPI3Doc(d, nil)
 		if err != nil {
 			return err
 		}
-		if err := doc.Validate(ctx); err != nil {
-			return fmt.Errorf("openapi document validation error: %w", err)
+		c.openApi3Doc = doc
+		return nil
+	}
+}
+
+// Swagger2 sets Swagger 2.0 Document using file path. The document is converted to OpenAPI 3.0.
+func Swagger2(l string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Swagger2DocLocation = l
+		return nil
+	}
+}
+
+// Swagger2FromData sets Swagger 2.0 Document from data. The document is converted to OpenAPI 3.0.
+func Swagger2FromData(d []byte) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		doc, err := parseSwagger2Doc(d)
+		if err != nil {
+			return err
 		}
 		c.openApi3Doc = doc
 		return nil
@@ -114,6 +248,31 @@ func SkipValidateResponse(skip bool) httpRunnerOption {
 	}
 }
 
+// Validators sets names of HTTP validators ( set by NamedHTTPValidator ) to be run in order after the OpenAPI validator.
+func Validators(names ...string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Validators = append(c.Validators, names...)
+		return nil
+	}
+}
+
+// FailOnGraphQLErrors sets whether to fail the step when the GraphQL response has `errors`.
+func FailOnGraphQLErrors(fail bool) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.FailOnGraphQLErrors = fail
+		return nil
+	}
+}
+
+// HTTPEndpoint sets the endpoint of HTTP runner using http.Handler ( HTTPRunnerWithHandler ). Other HTTP runners return an error.
+// The host is used for the Host header and cookies, and the path is used as the base path of requests.
+func HTTPEndpoint(endpoint string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Endpoint = endpoint
+		return nil
+	}
+}
+
 func NotFollowRedirect(nf bool) httpRunnerOption {
 	return func(c *httpRunnerConfig) error {
 		c.NotFollowRedirect = nf
@@ -170,6 +329,154 @@ func UseCookie(use bool) httpRunnerOption {
 	}
 }
 
+// HTTPIdleConnTimeout sets the maximum amount of time an idle connection will remain idle before closing itself.
+func HTTPIdleConnTimeout(timeout string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.IdleConnTimeout = timeout
+		return nil
+	}
+}
+
+// HTTPMaxIdleConns sets the maximum number of idle connections.
+func HTTPMaxIdleConns(n int) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.MaxIdleConns = n
+		return nil
+	}
+}
+
+// HTTPDisableKeepAlives sets whether to disable HTTP keep-alives.
+func HTTPDisableKeepAlives(disable bool) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.DisableKeepAlives = disable
+		return nil
+	}
+}
+
+// HTTPRetry sets retry of idempotent requests with exponential backoff on transport errors (e.g. connection reset, dial timeout).
+func HTTPRetry(count int, minInterval, maxInterval string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		if count < 0 {
+			return fmt.Errorf("retry count must be greater than or equal to 0: %d", count)
+		}
+		c.Retry = &httpRetryConfig{
+			Count:       count,
+			MinInterval: minInterval,
+			MaxInterval: maxInterval,
+		}
+		return nil
+	}
+}
+
+// HTTPProxy sets the proxy URL (http://, https://, socks5://) of HTTP runner.
+func HTTPProxy(proxyURL string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Proxy = proxyURL
+		return nil
+	}
+}
+
+// HTTPNoProxy sets comma-separated hosts (or domains, CIDRs) that should not use the proxy.
+func HTTPNoProxy(hosts string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.NoProxy = hosts
+		return nil
+	}
+}
+
+// HTTPResolve overrides the address to connect to for the host ( `host:port` or `host` ) of HTTP runner.
+func HTTPResolve(host, addr string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		if c.Resolve == nil {
+			c.Resolve = map[string]string{}
+		}
+		c.Resolve[host] = addr
+		return nil
+	}
+}
+
+// HTTPBasicAuth sets Basic authentication.
+func HTTPBasicAuth(username, password string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{
+			Type:     httpAuthTypeBasic,
+			Username: username,
+			Password: password,
+		}
+		return nil
+	}
+}
+
+// HTTPBearerAuth sets Bearer authentication. The token can contain expressions ( e.g. `{{ steps.login.res.body.token }}` ).
+func HTTPBearerAuth(token string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{
+			Type:  httpAuthTypeBearer,
+			Token: token,
+		}
+		return nil
+	}
+}
+
+// HTTPDigestAuth sets Digest authentication.
+func HTTPDigestAuth(username, password string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{
+			Type:     httpAuthTypeDigest,
+			Username: username,
+			Password: password,
+		}
+		return nil
+	}
+}
+
+// HTTPOAuth2ClientCredentials sets OAuth 2.0 authentication using client credentials grant.
+func HTTPOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{
+			Type:         httpAuthTypeOAuth2,
+			GrantType:    oauth2GrantTypeClientCredentials,
+			TokenURL:     tokenURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+		}
+		return nil
+	}
+}
+
+// HTTPOAuth2Password sets OAuth 2.0 authentication using resource owner password credentials grant.
+func HTTPOAuth2Password(tokenURL, clientID, clientSecret, username, password string, scopes ...string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{
+			Type:         httpAuthTypeOAuth2,
+			GrantType:    oauth2GrantTypePassword,
+			TokenURL:     tokenURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Username:     username,
+			Password:     password,
+			Scopes:       scopes,
+		}
+		return nil
+	}
+}
+
+// HTTPSigV4 sets AWS Signature Version 4 signing. If accessKeyID and secretAccessKey are empty, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN are used.
+func HTTPSigV4(region, service, accessKeyID, secretAccessKey, sessionToken string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{
+			Type:            httpAuthTypeSigV4,
+			Region:          region,
+			Service:         service,
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+		}
+		return nil
+	}
+}
+
 func TLS(useTLS bool) grpcRunnerOption {
 	return func(c *grpcRunnerConfig) error {
 		c.TLS = &useTLS
@@ -235,6 +542,261 @@ func ImportPaths(paths []string) grpcRunnerOption {
 	}
 }
 
+// Protosets append protosets ( FileDescriptorSet ).
+func Protosets(protosets []string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Protosets = unique(append(c.Protosets, protosets...))
+		return nil
+	}
+}
+
+// BufImage set buf image.
+func BufImage(p string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.BufImage = p
+		return nil
+	}
+}
+
+// GRPCProxy sets the proxy URL (http://, https://, socks5://) of gRPC runner.
+func GRPCProxy(proxyURL string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Proxy = proxyURL
+		return nil
+	}
+}
+
+// GRPCNoProxy sets comma-separated hosts (or domains, CIDRs) that should not use the proxy.
+func GRPCNoProxy(hosts string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.NoProxy = hosts
+		return nil
+	}
+}
+
+// GRPCResolve overrides the address to connect to for the host ( `host:port` or `host` ) of gRPC runner.
+func GRPCResolve(host, addr string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		if c.Resolve == nil {
+			c.Resolve = map[string]string{}
+		}
+		c.Resolve[host] = addr
+		return nil
+	}
+}
+
+// GRPCProtocol sets the protocol ( grpc, grpc-web or connect ) of gRPC runner.
+func GRPCProtocol(protocol string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Protocol = protocol
+		return nil
+	}
+}
+
+// GRPCCodec sets the codec ( proto or json ) of messages of gRPC runner using grpc-web or connect protocol.
+func GRPCCodec(codec string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Codec = codec
+		return nil
+	}
+}
+
+// GRPCCompression sets the compressor ( gzip ) of messages of gRPC runner.
+func GRPCCompression(compression string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Compression = compression
+		return nil
+	}
+}
+
+// GRPCKeepalive sets the keepalive parameters of gRPC runner.
+func GRPCKeepalive(t, timeout string, permitWithoutStream bool) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Keepalive = &grpcKeepaliveConfig{
+			Time:                t,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+		return nil
+	}
+}
+
+// GRPCMaxRecvMsgSize sets the maximum message size in bytes the gRPC runner can receive.
+func GRPCMaxRecvMsgSize(size int) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.MaxRecvMsgSize = size
+		return nil
+	}
+}
+
+// GRPCMaxSendMsgSize sets the maximum message size in bytes the gRPC runner can send.
+func GRPCMaxSendMsgSize(size int) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.MaxSendMsgSize = size
+		return nil
+	}
+}
+
+// GRPCRetryPolicy sets the retry policy of gRPC runner.
+func GRPCRetryPolicy(maxAttempts int, initialBackoff, maxBackoff string, backoffMultiplier float64, retryableStatusCodes []string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.RetryPolicy = &grpcRetryPolicyConfig{
+			MaxAttempts:          maxAttempts,
+			InitialBackoff:       initialBackoff,
+			MaxBackoff:           maxBackoff,
+			BackoffMultiplier:    backoffMultiplier,
+			RetryableStatusCodes: retryableStatusCodes,
+		}
+		return nil
+	}
+}
+
+// GRPCAuthority sets the value of :authority pseudo-header ( and the server name for TLS ) of gRPC runner.
+func GRPCAuthority(authority string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Authority = authority
+		return nil
+	}
+}
+
+// GRPCBearerCredentials sets the static bearer token sent as per-RPC credentials of gRPC runner.
+func GRPCBearerCredentials(token string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		if c.Credentials == nil {
+			c.Credentials = &grpcCredentialsConfig{}
+		}
+		c.Credentials.Bearer = &grpcBearerConfig{Token: token}
+		return nil
+	}
+}
+
+// GRPCOAuth2Credentials sets the OAuth2 client credentials flow to get the token sent as per-RPC credentials of gRPC runner.
+func GRPCOAuth2Credentials(tokenURL, clientID, clientSecret string, scopes []string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		if c.Credentials == nil {
+			c.Credentials = &grpcCredentialsConfig{}
+		}
+		c.Credentials.OAuth2 = &grpcOAuth2Config{
+			TokenURL:     tokenURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+		}
+		return nil
+	}
+}
+
+// GRPCJWTCredentials sets the PEM encoded key ( or the secret for HMAC ) to sign the JWT sent as per-RPC credentials of gRPC runner.
+func GRPCJWTCredentials(key []byte, issuer, subject string, audience []string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		if c.Credentials == nil {
+			c.Credentials = &grpcCredentialsConfig{}
+		}
+		c.Credentials.JWT = &grpcJWTConfig{
+			Issuer:   issuer,
+			Subject:  subject,
+			Audience: audience,
+			key:      key,
+		}
+		return nil
+	}
+}
+
+// GRPCInsecureCredentials allows sending per-RPC credentials over the connection without TLS.
+func GRPCInsecureCredentials(insecure bool) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		if c.Credentials == nil {
+			c.Credentials = &grpcCredentialsConfig{}
+		}
+		c.Credentials.Insecure = insecure
+		return nil
+	}
+}
+
+// GRPCServerName sets the server name to verify the certificate and to send as SNI of gRPC runner.
+func GRPCServerName(serverName string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.ServerName = serverName
+		return nil
+	}
+}
+
+// WSHeader sets the header of the WebSocket opening handshake request.
+func WSHeader(k, v string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		if c.Headers == nil {
+			c.Headers = map[string]string{}
+		}
+		c.Headers[k] = v
+		return nil
+	}
+}
+
+// WSSubprotocols append subprotocols requested in the WebSocket opening handshake.
+func WSSubprotocols(protocols ...string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Subprotocols = unique(append(c.Subprotocols, protocols...))
+		return nil
+	}
+}
+
+func WSCACert(path string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.CACert = path
+		return nil
+	}
+}
+
+func WSCert(path string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Cert = path
+		return nil
+	}
+}
+
+func WSKey(path string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Key = path
+		return nil
+	}
+}
+
+func WSCACertFromData(b []byte) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.cacert = b
+		return nil
+	}
+}
+
+func WSCertFromData(b []byte) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.cert = b
+		return nil
+	}
+}
+
+func WSKeyFromData(b []byte) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.key = b
+		return nil
+	}
+}
+
+func WSSkipVerify(skip bool) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.SkipVerify = skip
+		return nil
+	}
+}
+
+// WSTimeout sets the timeout of the opening handshake and each send/receive.
+func WSTimeout(timeout string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Timeout = timeout
+		return nil
+	}
+}
+
 func SSHConfig(p string) sshRunnerOption {
 	return func(c *sshRunnerConfig) error {
 		c.SSHConfig = p
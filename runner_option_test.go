@@ -1,6 +1,10 @@
 package runn
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
 
 func TestOpenApi3(t *testing.T) {
 	c := &httpRunnerConfig{}
@@ -90,3 +94,33 @@ func TestUseCookie(t *testing.T) {
 		t.Errorf("got %v\nwant %v", got, want)
 	}
 }
+
+func TestHTTPRetry(t *testing.T) {
+	tests := []struct {
+		count       int
+		minInterval string
+		maxInterval string
+		want        *httpRetryConfig
+		wantErr     bool
+	}{
+		{3, "100ms", "1sec", &httpRetryConfig{Count: 3, MinInterval: "100ms", MaxInterval: "1sec"}, false},
+		{0, "", "", &httpRetryConfig{}, false},
+		{-1, "", "", nil, true},
+	}
+	for _, tt := range tests {
+		c := &httpRunnerConfig{}
+		opt := HTTPRetry(tt.count, tt.minInterval, tt.maxInterval)
+		if err := opt(c); err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		if diff := cmp.Diff(c.Retry, tt.want, nil); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -14,6 +14,8 @@ type step struct {
 	dbQuery       map[string]any
 	grpcRunner    *grpcRunner
 	grpcRequest   map[string]any
+	wsRunner      *wsRunner
+	wsRequest     map[string]any
 	cdpRunner     *cdpRunner
 	cdpActions    map[string]any
 	sshRunner     *sshRunner
@@ -52,6 +54,8 @@ func (s *step) generateTrail() Trail {
 		tr.StepRunnerType = RunnerTypeDB
 	case s.grpcRunner != nil && s.grpcRequest != nil:
 		tr.StepRunnerType = RunnerTypeGRPC
+	case s.wsRunner != nil && s.wsRequest != nil:
+		tr.StepRunnerType = RunnerTypeWS
 	case s.cdpRunner != nil && s.cdpActions != nil:
 		tr.StepRunnerType = RunnerTypeCDP
 	case s.sshRunner != nil && s.sshCommand != nil:
@@ -2,10 +2,8 @@ package runn
 
 import (
 	"errors"
-	"net/http"
 	"os"
 	"strings"
-	"time"
 )
 
 const (
@@ -32,7 +30,7 @@ type store struct {
 	parentVars  map[string]any
 	useMap      bool // Use map syntax in `steps:`.
 	loopIndex   *int
-	cookies     map[string]map[string]*http.Cookie
+	cookieJar   *cookieJar
 }
 
 func (s *store) recordAsMapped(k string, v map[string]any) {
@@ -119,28 +117,6 @@ func (s *store) recordToLatest(key string, value any) error {
 	return errors.New("failed to record")
 }
 
-func (s *store) recordToCookie(cookies []*http.Cookie) {
-	cookieMap := make(map[string]map[string]*http.Cookie)
-	for _, cookie := range cookies {
-		domain := cookie.Domain
-		if domain == "" {
-			domain = "localhost"
-		}
-		keyMap, ok := cookieMap[domain]
-		if !ok || keyMap == nil {
-			keyMap = make(map[string]*http.Cookie)
-		}
-		if !cookie.Expires.IsZero() && cookie.Expires.Before(time.Now()) {
-			// Remove expired cookie
-			delete(keyMap, cookie.Name)
-		} else {
-			keyMap[cookie.Name] = cookie
-		}
-		cookieMap[domain] = keyMap
-	}
-	s.cookies = cookieMap
-}
-
 func (s *store) toNormalizedMap() map[string]any {
 	store := map[string]any{}
 	store[storeEnvKey] = envMap()
@@ -159,8 +135,8 @@ func (s *store) toNormalizedMap() map[string]any {
 	if s.loopIndex != nil {
 		store[loopCountVarKey] = *s.loopIndex
 	}
-	if s.cookies != nil {
-		store[storeCookieKey] = s.cookies
+	if s.cookieJar != nil && s.cookieJar.hasRecorded() {
+		store[storeCookieKey] = s.cookieJar.toMap()
 	}
 	return store
 }
@@ -186,8 +162,8 @@ func (s *store) toMap() map[string]any {
 	if s.loopIndex != nil {
 		store[loopCountVarKey] = *s.loopIndex
 	}
-	if s.cookies != nil {
-		store[storeCookieKey] = s.cookies
+	if s.cookieJar != nil && s.cookieJar.hasRecorded() {
+		store[storeCookieKey] = s.cookieJar.toMap()
 	}
 	return store
 }
@@ -2,9 +2,9 @@ package runn
 
 import (
 	"net/http"
+	"net/url"
 	"sort"
 	"testing"
-	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -58,7 +58,27 @@ func TestToMap(t *testing.T) {
 		},
 		{
 			store{
-				cookies: map[string]map[string]*http.Cookie{},
+				cookieJar: func() *cookieJar {
+					jar := newCookieJar()
+					jar.SetCookies(&url.URL{Scheme: "http", Host: "example.com"}, []*http.Cookie{{Name: "key", Value: "value"}})
+					return jar
+				}(),
+			},
+			[]string{"env", "vars", "steps", "cookies"},
+		},
+		{
+			store{
+				cookieJar: newCookieJar(),
+			},
+			[]string{"env", "vars", "steps"},
+		},
+		{
+			store{
+				cookieJar: func() *cookieJar {
+					jar := newCookieJar()
+					jar.SetCookies(&url.URL{Scheme: "http", Host: "example.com"}, []*http.Cookie{{Name: "key", Value: "value"}, {Name: "key", MaxAge: -1}})
+					return jar
+				}(),
 			},
 			[]string{"env", "vars", "steps", "cookies"},
 		},
@@ -129,7 +149,27 @@ func TestToNormalizedMap(t *testing.T) {
 		},
 		{
 			store{
-				cookies: map[string]map[string]*http.Cookie{},
+				cookieJar: func() *cookieJar {
+					jar := newCookieJar()
+					jar.SetCookies(&url.URL{Scheme: "http", Host: "example.com"}, []*http.Cookie{{Name: "key", Value: "value"}})
+					return jar
+				}(),
+			},
+			[]string{"env", "vars", "steps", "cookies"},
+		},
+		{
+			store{
+				cookieJar: newCookieJar(),
+			},
+			[]string{"env", "vars", "steps"},
+		},
+		{
+			store{
+				cookieJar: func() *cookieJar {
+					jar := newCookieJar()
+					jar.SetCookies(&url.URL{Scheme: "http", Host: "example.com"}, []*http.Cookie{{Name: "key", Value: "value"}, {Name: "key", MaxAge: -1}})
+					return jar
+				}(),
 			},
 			[]string{"env", "vars", "steps", "cookies"},
 		},
@@ -150,100 +190,3 @@ func TestToNormalizedMap(t *testing.T) {
 		}
 	}
 }
-
-func TestRecordToCookie(t *testing.T) {
-	cookie1 := http.Cookie{
-		Name:   "key1",
-		Value:  "value1",
-		Domain: "example.com",
-	}
-	cookie2 := http.Cookie{
-		Name:   "key2",
-		Value:  "value2",
-		Domain: "example.com",
-	}
-	cookie3 := http.Cookie{
-		Name:   "key3",
-		Value:  "value3",
-		Domain: "sub.example.com",
-	}
-	cookie4 := http.Cookie{
-		Name:   "key1",
-		Value:  "value4",
-		Domain: "example.com",
-	}
-	cookie5 := http.Cookie{
-		Name:    "key1",
-		Value:   "value4",
-		Domain:  "example.com",
-		Expires: time.Now(),
-	}
-	tests := []struct {
-		store   store
-		cookies []*http.Cookie
-		want    map[string]map[string]*http.Cookie
-	}{
-		{
-			store{},
-			[]*http.Cookie{},
-			map[string]map[string]*http.Cookie{},
-		},
-		{
-			store{},
-			[]*http.Cookie{&cookie1},
-			map[string]map[string]*http.Cookie{
-				"example.com": {
-					"key1": &cookie1,
-				},
-			},
-		},
-		{
-			store{},
-			[]*http.Cookie{&cookie1, &cookie2},
-			map[string]map[string]*http.Cookie{
-				"example.com": {
-					"key1": &cookie1,
-					"key2": &cookie2,
-				},
-			},
-		},
-		{
-			store{},
-			[]*http.Cookie{&cookie1, &cookie2, &cookie3},
-			map[string]map[string]*http.Cookie{
-				"example.com": {
-					"key1": &cookie1,
-					"key2": &cookie2,
-				},
-				"sub.example.com": {
-					"key3": &cookie3,
-				},
-			},
-		},
-		{
-			store{
-				cookies: map[string]map[string]*http.Cookie{
-					"example.com": {
-						// Override
-						"key1": &cookie4,
-					},
-				},
-			},
-			[]*http.Cookie{&cookie5},
-			map[string]map[string]*http.Cookie{
-				// Expire
-				"example.com": {},
-			},
-		},
-	}
-	for _, tt := range tests {
-		tt.store.recordToCookie(tt.cookies)
-		got := tt.store.toMap()["cookies"]
-		opts := []cmp.Option{
-			cmp.AllowUnexported(store{}),
-		}
-		if diff := cmp.Diff(got, tt.want, opts...); diff != "" {
-			t.Error(diff)
-		}
-	}
-}
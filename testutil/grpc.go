@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/k1LoW/grpcstub"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -94,6 +95,23 @@ func GRPCServer(t *testing.T, useTLS bool, disableReflection bool) *grpcstub.Ser
 	}())
 
 	// error responses
+	ts.Method("grpctest.GrpcTestService/Hello").Match(func(r *grpcstub.Request) bool {
+		h := r.Headers.Get("error-details")
+		return len(h) > 0
+	}).Status(func() *status.Status {
+		s, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(
+			&errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "name", Description: "name is required"},
+				},
+			},
+			&errdetails.ErrorInfo{Reason: "INVALID_NAME", Domain: "example.com"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}())
 	ts.Method("grpctest.GrpcTestService/Hello").Match(func(r *grpcstub.Request) bool {
 		h := r.Headers.Get("error")
 		return len(h) > 0
@@ -0,0 +1,105 @@
+package testutil
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// ProxyServer is a forward proxy server for testing that supports plain HTTP requests and CONNECT tunneling.
+type ProxyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+// Requests returns the hosts requested through the proxy.
+func (s *ProxyServer) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// HTTPProxyServer returns a forward proxy server. If user is not empty, Proxy-Authorization ( Basic ) is required.
+func HTTPProxyServer(t *testing.T, user, password string) *ProxyServer {
+	t.Helper()
+	s := &ProxyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user != "" {
+			want := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+			if r.Header.Get("Proxy-Authorization") != want {
+				w.Header().Set("Proxy-Authenticate", `Basic realm="runn"`)
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Host)
+		s.mu.Unlock()
+		if r.Method == http.MethodConnect {
+			tunnel(w, r)
+			return
+		}
+		forward(w, r)
+	}))
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+func tunnel(w http.ResponseWriter, r *http.Request) {
+	dst, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		_ = dst.Close()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	src, _, err := hj.Hijack()
+	if err != nil {
+		_ = dst.Close()
+		return
+	}
+	go func() {
+		defer dst.Close()
+		defer src.Close()
+		_, _ = io.Copy(dst, src)
+	}()
+	go func() {
+		defer dst.Close()
+		defer src.Close()
+		_, _ = io.Copy(src, dst)
+	}()
+}
+
+func forward(w http.ResponseWriter, r *http.Request) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.Header = r.Header.Clone()
+	req.Header.Del("Proxy-Authorization")
+	req.Header.Del("Proxy-Connection")
+	res, err := (&http.Transport{}).RoundTrip(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	for k, v := range res.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
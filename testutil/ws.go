@@ -0,0 +1,71 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// WSEchoServer returns a WebSocket server that echoes back received messages.
+// The text message "handshake" returns the selected subprotocol and the request headers of the opening handshake as JSON.
+// The text message "bye" makes the server close the connection with code 1000 and reason "bye".
+func WSEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var protocol string
+		u := ws.HTTPUpgrader{
+			Protocol: func(p string) bool {
+				protocol = p
+				return true
+			},
+		}
+		conn, _, _, err := u.Upgrade(r, w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			b, op, err := wsutil.ReadClientData(conn)
+			if err != nil {
+				return
+			}
+			switch {
+			case op == ws.OpText && string(b) == "handshake":
+				h := map[string]string{}
+				for k := range r.Header {
+					h[strings.ToLower(k)] = r.Header.Get(k)
+				}
+				res, err := json.Marshal(map[string]any{
+					"protocol": protocol,
+					"headers":  h,
+				})
+				if err != nil {
+					return
+				}
+				if err := wsutil.WriteServerText(conn, res); err != nil {
+					return
+				}
+			case op == ws.OpText && string(b) == "bye":
+				if err := ws.WriteFrame(conn, ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusNormalClosure, "bye"))); err != nil {
+					return
+				}
+				// Wait for the close frame from the client.
+				_, _, _ = wsutil.ReadClientData(conn)
+				return
+			default:
+				if err := wsutil.WriteServerMessage(conn, op, b); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	return ts
+}
@@ -9,6 +9,7 @@ const (
 	TrailTypeStep       TrailType = "step"
 	TrailTypeBeforeFunc TrailType = "beforeFunc"
 	TrailTypeAfterFunc  TrailType = "afterFunc"
+	TrailTypeHTTPTiming TrailType = "httpTiming"
 )
 
 type RunnerType string
@@ -17,6 +18,7 @@ const (
 	RunnerTypeHTTP    RunnerType = "http"
 	RunnerTypeDB      RunnerType = "db"
 	RunnerTypeGRPC    RunnerType = "grpc"
+	RunnerTypeWS      RunnerType = "ws"
 	RunnerTypeCDP     RunnerType = "cdp"
 	RunnerTypeSSH     RunnerType = "ssh"
 	RunnerTypeExec    RunnerType = "exec"
@@ -50,6 +52,8 @@ func (tr Trail) String() string { //nostyle:recvtype
 		return fmt.Sprintf("beforeFunc[%d]", tr.FuncIndex)
 	case TrailTypeAfterFunc:
 		return fmt.Sprintf("afterFunc[%d]", tr.FuncIndex)
+	case TrailTypeHTTPTiming:
+		return fmt.Sprintf("httpTiming[%s]", tr.Desc)
 	default:
 		return "invalid"
 	}
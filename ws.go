@@ -0,0 +1,411 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/duration"
+	"github.com/k1LoW/runn/version"
+)
+
+const defaultWSTimeout = 30 * time.Second
+
+type WSOp string
+
+const (
+	WSOpMessage WSOp = "message"
+	WSOpReceive WSOp = "receive"
+	WSOpClose   WSOp = "close"
+)
+
+type WSMessageType string
+
+const (
+	WSMessageText   WSMessageType = "text"
+	WSMessageJSON   WSMessageType = "json"
+	WSMessageBinary WSMessageType = "binary"
+)
+
+const (
+	wsStoreMessageKey  = "message"
+	wsStoreMessagesKey = "messages"
+	wsStoreCloseKey    = "close"
+	wsStoreResponseKey = "res"
+
+	wsMessageTypeKey    = "type"
+	wsMessageDataKey    = "data"
+	wsMessageRawDataKey = "rawData"
+	wsCloseCodeKey      = "code"
+	wsCloseReasonKey    = "reason"
+)
+
+type wsRunner struct {
+	name         string
+	endpoint     *url.URL
+	headers      http.Header
+	subprotocols []string
+	cacert       []byte
+	cert         []byte
+	key          []byte
+	skipVerify   bool
+	timeout      time.Duration
+	conn         net.Conn
+	operator     *operator
+}
+
+type wsMessage struct {
+	op      WSOp
+	typ     WSMessageType
+	params  any
+	timeout time.Duration
+}
+
+type wsRequest struct {
+	headers  http.Header
+	messages []*wsMessage
+	timeout  time.Duration
+}
+
+func newWSRunner(name, endpoint string) (*wsRunner, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if !isWSEndpoint(endpoint) {
+		return nil, fmt.Errorf("invalid WebSocket endpoint: %s", endpoint)
+	}
+	return &wsRunner{
+		name:     name,
+		endpoint: u,
+		headers:  http.Header{},
+		timeout:  defaultWSTimeout,
+	}, nil
+}
+
+func isWSEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://")
+}
+
+// setConfig sets options of the runner. Relative file paths are resolved from root.
+func (rnr *wsRunner) setConfig(c *wsRunnerConfig, root string) error {
+	for k, v := range c.Headers {
+		rnr.headers.Set(k, v)
+	}
+	rnr.subprotocols = c.Subprotocols
+	if len(c.cacert) != 0 {
+		rnr.cacert = c.cacert
+	} else if c.CACert != "" {
+		b, err := readFile(fp(c.CACert, root))
+		if err != nil {
+			return err
+		}
+		rnr.cacert = b
+	}
+	if len(c.cert) != 0 {
+		rnr.cert = c.cert
+	} else if c.Cert != "" {
+		b, err := readFile(fp(c.Cert, root))
+		if err != nil {
+			return err
+		}
+		rnr.cert = b
+	}
+	if len(c.key) != 0 {
+		rnr.key = c.key
+	} else if c.Key != "" {
+		b, err := readFile(fp(c.Key, root))
+		if err != nil {
+			return err
+		}
+		rnr.key = b
+	}
+	rnr.skipVerify = c.SkipVerify
+	if c.Timeout != "" {
+		d, err := duration.Parse(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout in WSRunnerConfig is invalid: %w", err)
+		}
+		rnr.timeout = d
+	}
+	return nil
+}
+
+func (rnr *wsRunner) Close() error {
+	if rnr.conn == nil {
+		return nil
+	}
+	err := rnr.conn.Close()
+	rnr.conn = nil
+	return err
+}
+
+func (rnr *wsRunner) Run(ctx context.Context, r *wsRequest) error {
+	rnr.operator.capturers.captureWSStart(rnr.name, rnr.endpoint.String())
+	defer rnr.operator.capturers.captureWSEnd(rnr.name, rnr.endpoint.String())
+	if rnr.conn == nil {
+		if err := rnr.connect(ctx, r.headers); err != nil {
+			return err
+		}
+	}
+	timeout := rnr.timeout
+	if r.timeout > 0 {
+		timeout = r.timeout
+	}
+	d := map[string]any{
+		wsStoreMessageKey: nil,
+	}
+	messages := []any{}
+L:
+	for _, m := range r.messages {
+		switch m.op {
+		case WSOpMessage:
+			if err := rnr.send(m, timeout); err != nil {
+				_ = rnr.Close()
+				return err
+			}
+		case WSOpReceive:
+			t := timeout
+			if m.timeout > 0 {
+				t = m.timeout
+			}
+			msg, closed, err := rnr.receive(ctx, t)
+			if err != nil {
+				_ = rnr.Close()
+				return err
+			}
+			if closed != nil {
+				// The server closed the connection.
+				d[wsStoreCloseKey] = closed
+				_ = rnr.Close()
+				break L
+			}
+			d[wsStoreMessageKey] = msg
+			messages = append(messages, msg)
+		case WSOpClose:
+			closed, err := rnr.close(ctx, timeout)
+			if err != nil {
+				return err
+			}
+			if closed != nil {
+				d[wsStoreCloseKey] = closed
+			}
+			break L
+		default:
+			return fmt.Errorf("invalid op: %v", m.op)
+		}
+	}
+	d[wsStoreMessagesKey] = messages
+
+	rnr.operator.record(map[string]any{
+		wsStoreResponseKey: d,
+	})
+
+	return nil
+}
+
+func (rnr *wsRunner) connect(ctx context.Context, headers http.Header) error {
+	h := rnr.headers.Clone()
+	for k, v := range headers {
+		h[k] = v
+	}
+	if h.Get("User-Agent") == "" {
+		h.Set("User-Agent", fmt.Sprintf("runn/%s", version.Version))
+	}
+	d := ws.Dialer{
+		Header:    ws.HandshakeHeaderHTTP(h),
+		Protocols: rnr.subprotocols,
+		Timeout:   rnr.timeout,
+	}
+	if rnr.endpoint.Scheme == "wss" {
+		tlsc := &tls.Config{MinVersion: tls.VersionTLS12}
+		if len(rnr.cert) != 0 {
+			certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
+			if err != nil {
+				return err
+			}
+			tlsc.Certificates = []tls.Certificate{certificate}
+		}
+		if rnr.skipVerify {
+			//#nosec G402
+			tlsc.InsecureSkipVerify = true
+		} else if len(rnr.cacert) != 0 {
+			certpool, err := x509.SystemCertPool()
+			if err != nil {
+				// FIXME for Windows
+				// ref: https://github.com/golang/go/issues/18609
+				certpool = x509.NewCertPool()
+			}
+			if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
+				return errors.New("failed to append cacert")
+			}
+			tlsc.RootCAs = certpool
+		}
+		d.TLSConfig = tlsc
+	}
+	conn, br, hs, err := d.Dial(ctx, rnr.endpoint.String())
+	if err != nil {
+		return err
+	}
+	if br != nil {
+		// The server has sent frames right after the handshake.
+		conn = &bufferedConn{Conn: conn, r: br}
+	}
+	rnr.conn = conn
+	rnr.operator.capturers.captureWSHandshake(h, hs.Protocol)
+	return nil
+}
+
+func (rnr *wsRunner) send(m *wsMessage, timeout time.Duration) error {
+	// Lazy expand due to the possibility of computing variables between multiple messages.
+	e, err := rnr.operator.expandBeforeRecord(m.params)
+	if err != nil {
+		return err
+	}
+	var (
+		op ws.OpCode
+		b  []byte
+	)
+	switch m.typ {
+	case WSMessageText:
+		s, ok := e.(string)
+		if !ok {
+			return fmt.Errorf("invalid text message: %v", e)
+		}
+		op = ws.OpText
+		b = []byte(s)
+	case WSMessageJSON:
+		op = ws.OpText
+		b, err = json.Marshal(e)
+		if err != nil {
+			return err
+		}
+	case WSMessageBinary:
+		s, ok := e.(string)
+		if !ok {
+			return fmt.Errorf("invalid binary message (base64 encoded string is required): %v", e)
+		}
+		op = ws.OpBinary
+		b, err = base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid binary message (base64 encoded string is required): %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid message type: %s", m.typ)
+	}
+	rnr.operator.capturers.captureWSRequestMessage(newWSMessageValue(op, b))
+	_ = rnr.conn.SetWriteDeadline(time.Now().Add(timeout))
+	defer func() {
+		_ = rnr.conn.SetWriteDeadline(time.Time{})
+	}()
+	return wsutil.WriteClientMessage(rnr.conn, op, b)
+}
+
+// receive receives a data message. If the server closes the connection, it returns the close code and reason.
+func (rnr *wsRunner) receive(ctx context.Context, timeout time.Duration) (map[string]any, map[string]any, error) {
+	dl := time.Now().Add(timeout)
+	if cdl, ok := ctx.Deadline(); ok && cdl.Before(dl) {
+		dl = cdl
+	}
+	_ = rnr.conn.SetReadDeadline(dl)
+	defer func() {
+		_ = rnr.conn.SetReadDeadline(time.Time{})
+	}()
+	b, op, err := wsutil.ReadServerData(rnr.conn)
+	if err != nil {
+		var cerr wsutil.ClosedError
+		if errors.As(err, &cerr) {
+			closed := newWSCloseValue(cerr)
+			rnr.operator.capturers.captureWSClose(int(cerr.Code), cerr.Reason)
+			return nil, closed, nil
+		}
+		var nerr net.Error
+		if errors.As(err, &nerr) && nerr.Timeout() {
+			return nil, nil, fmt.Errorf("receive timeout (%s): %w", timeout, err)
+		}
+		return nil, nil, err
+	}
+	msg := newWSMessageValue(op, b)
+	rnr.operator.capturers.captureWSResponseMessage(msg)
+	return msg, nil, nil
+}
+
+// close sends a close frame and waits for the close frame from the server.
+func (rnr *wsRunner) close(ctx context.Context, timeout time.Duration) (map[string]any, error) {
+	defer func() {
+		_ = rnr.Close()
+	}()
+	rnr.operator.capturers.captureWSClientClose()
+	_ = rnr.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if err := wsutil.WriteClientMessage(rnr.conn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusNormalClosure, "")); err != nil {
+		return nil, err
+	}
+	dl := time.Now().Add(timeout)
+	if cdl, ok := ctx.Deadline(); ok && cdl.Before(dl) {
+		dl = cdl
+	}
+	_ = rnr.conn.SetReadDeadline(dl)
+	// The close frame has already been sent, so replies to control frames are discarded.
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{rnr.conn, io.Discard}
+	for {
+		b, op, err := wsutil.ReadServerData(rw)
+		if err != nil {
+			var cerr wsutil.ClosedError
+			if errors.As(err, &cerr) {
+				rnr.operator.capturers.captureWSClose(int(cerr.Code), cerr.Reason)
+				return newWSCloseValue(cerr), nil
+			}
+			// The server closed the connection without a close frame.
+			return nil, nil
+		}
+		// Data messages received before the close frame are discarded.
+		rnr.operator.capturers.captureWSResponseMessage(newWSMessageValue(op, b))
+	}
+}
+
+func newWSMessageValue(op ws.OpCode, b []byte) map[string]any {
+	if op == ws.OpBinary || !utf8.Valid(b) {
+		s := base64.StdEncoding.EncodeToString(b)
+		return map[string]any{
+			wsMessageTypeKey:    string(WSMessageBinary),
+			wsMessageDataKey:    s,
+			wsMessageRawDataKey: s,
+		}
+	}
+	m := map[string]any{
+		wsMessageTypeKey:    string(WSMessageText),
+		wsMessageDataKey:    string(b),
+		wsMessageRawDataKey: string(b),
+	}
+	trimmed := strings.TrimSpace(string(b))
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var v any
+		if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+			m[wsMessageDataKey] = v
+		}
+	}
+	return m
+}
+
+func newWSCloseValue(cerr wsutil.ClosedError) map[string]any {
+	return map[string]any{
+		wsCloseCodeKey:   int(cerr.Code),
+		wsCloseReasonKey: cerr.Reason,
+	}
+}
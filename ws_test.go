@@ -0,0 +1,231 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+	"github.com/k1LoW/runn/version"
+)
+
+func TestWSRunner(t *testing.T) {
+	tests := []struct {
+		name         string
+		subprotocols []string
+		req          *wsRequest
+		wantMessages []any
+		wantClose    map[string]any
+		wantErr      bool
+	}{
+		{
+			"text",
+			nil,
+			&wsRequest{
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageText, params: "hello"},
+					{op: WSOpReceive},
+				},
+			},
+			[]any{
+				map[string]any{"type": "text", "data": "hello", "rawData": "hello"},
+			},
+			nil,
+			false,
+		},
+		{
+			"json and binary",
+			nil,
+			&wsRequest{
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageJSON, params: map[string]any{"name": "alice"}},
+					{op: WSOpMessage, typ: WSMessageBinary, params: "aGVsbG8="},
+					{op: WSOpReceive},
+					{op: WSOpReceive},
+				},
+			},
+			[]any{
+				map[string]any{"type": "text", "data": map[string]any{"name": "alice"}, "rawData": `{"name":"alice"}`},
+				map[string]any{"type": "binary", "data": "aGVsbG8=", "rawData": "aGVsbG8="},
+			},
+			nil,
+			false,
+		},
+		{
+			"headers and subprotocols",
+			[]string{"chat", "superchat"},
+			&wsRequest{
+				headers: http.Header{"X-Token": []string{"secret"}},
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageText, params: "handshake"},
+					{op: WSOpReceive},
+				},
+			},
+			[]any{
+				map[string]any{
+					"type": "text",
+					"data": map[string]any{
+						"protocol": "chat",
+						"headers": map[string]any{
+							"connection":             "Upgrade",
+							"upgrade":                "websocket",
+							"sec-websocket-key":      "",
+							"sec-websocket-version":  "13",
+							"sec-websocket-protocol": "chat, superchat",
+							"user-agent":             fmt.Sprintf("runn/%s", version.Version),
+							"x-token":                "secret",
+						},
+					},
+				},
+			},
+			nil,
+			false,
+		},
+		{
+			"close by client",
+			nil,
+			&wsRequest{
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageText, params: "hello"},
+					{op: WSOpReceive},
+					{op: WSOpClose},
+				},
+			},
+			[]any{
+				map[string]any{"type": "text", "data": "hello", "rawData": "hello"},
+			},
+			map[string]any{"code": 1000, "reason": ""},
+			false,
+		},
+		{
+			"close by server",
+			nil,
+			&wsRequest{
+				messages: []*wsMessage{
+					{op: WSOpMessage, typ: WSMessageText, params: "bye"},
+					{op: WSOpReceive},
+					{op: WSOpReceive},
+				},
+			},
+			[]any{},
+			map[string]any{"code": 1000, "reason": "bye"},
+			false,
+		},
+		{
+			"receive timeout",
+			nil,
+			&wsRequest{
+				messages: []*wsMessage{
+					{op: WSOpReceive, timeout: 100 * time.Millisecond},
+				},
+			},
+			nil,
+			nil,
+			true,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ts := testutil.WSEchoServer(t)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newWSRunner("ws", strings.Replace(ts.URL, "http://", "ws://", 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.operator = o
+			r.subprotocols = tt.subprotocols
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			if err := r.Run(ctx, tt.req); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if want := 1; len(o.store.steps) != want {
+				t.Fatalf("got %v want %v", len(o.store.steps), want)
+			}
+			res, ok := o.store.steps[0]["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid steps res: %v", o.store.steps[0]["res"])
+			}
+			got := res["messages"]
+			for _, m := range got.([]any) {
+				mm := m.(map[string]any)
+				if d, ok := mm["data"].(map[string]any); ok {
+					if h, ok := d["headers"].(map[string]any); ok {
+						// Random value
+						h["sec-websocket-key"] = ""
+						delete(mm, "rawData")
+					}
+				}
+			}
+			if diff := cmp.Diff(got, tt.wantMessages); diff != "" {
+				t.Error(diff)
+			}
+			if tt.wantClose != nil {
+				if diff := cmp.Diff(res["close"], tt.wantClose); diff != "" {
+					t.Error(diff)
+				}
+			}
+		})
+	}
+}
+
+func TestWSRunnerKeepConnection(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.WSEchoServer(t)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newWSRunner("ws", strings.Replace(ts.URL, "http://", "ws://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.operator = o
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	// The connection is kept between steps until `close`.
+	if err := r.Run(ctx, &wsRequest{
+		messages: []*wsMessage{
+			{op: WSOpMessage, typ: WSMessageText, params: "first"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Run(ctx, &wsRequest{
+		messages: []*wsMessage{
+			{op: WSOpReceive},
+			{op: WSOpClose},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if r.conn != nil {
+		t.Error("the connection should be closed")
+	}
+	res, ok := o.store.steps[1]["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid steps res: %v", o.store.steps[1]["res"])
+	}
+	want := map[string]any{"type": "text", "data": "first", "rawData": "first"}
+	if diff := cmp.Diff(res["message"], want); diff != "" {
+		t.Error(diff)
+	}
+}